@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/envfile"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	addImage string
+	keepData bool
+	addCmd   = &cobra.Command{
+		Use:     "add <service>",
+		Short:   "Add a service to an existing project",
+		Example: "blah add redis",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			addService(context.Background(), args[0])
+		},
+	}
+	removeCmd = &cobra.Command{
+		Use:     "remove <service>",
+		Short:   "Remove a service and its container from an existing project",
+		Example: "blah remove redis --keep-data",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			removeService(context.Background(), args[0])
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(removeCmd)
+	addCmd.Flags().StringVar(&addImage, "image", "", "Image of the service EG. redis:7")
+	addCmd.Flags().StringVar(&pull, "pull", string(containers.PullMissing), "Image pull policy always|missing|never")
+	addCmd.Flags().BoolVar(&runAsHostUser, "host-user", false, "Run the database as your user so the files it writes to database/ stay yours")
+	addCmd.Flags().StringVar(&storage, "storage", "", "Keep the data in a directory of database/ or a named volume bind|volume, defaults to the storage of the project")
+	removeCmd.Flags().BoolVar(&keepData, "keep-data", false, "Keep the data directory or volume of the service")
+	removeCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Remove the data directory or volume without asking")
+}
+
+// Returns the project name of the working directory EG. myproj
+func currentProjectName() string {
+	return path.Base(utils.GetAbsChild("."))
+}
+
+// Returns the service with the given name fatally exits if blah does not know it
+func findService(name string) service {
+	services := allServices()
+	for i := range services {
+		if services[i].name == name {
+			return services[i]
+		}
+	}
+	color.PrintFatal(fmt.Errorf("Unknown service %s should be one of %s", name, strings.Join(serviceNames(services), ", ")))
+	return service{}
+}
+
+// Runs the setup of a service, pulls its image and creates its container in the current project
+func addService(ctx context.Context, name string) {
+	s := findService(name)
+	policy, err := containers.ParsePullPolicy(pull)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	pController, cController := loadProject(ctx)
+	projectName := currentProjectName()
+
+	_, err = pController.FindContainerByService("", s.name, utils.PrefixProjectName(projectName, s.name))
+	if err == nil {
+		color.PrintFatal(fmt.Errorf("%s is already part of project %s", s.name, projectName))
+	}
+	if err != gorm.ErrRecordNotFound {
+		color.PrintFatal(err)
+	}
+
+	network := projectNetwork(projectName)
+	if err := ensureNetwork(ctx, cController, projectName, profile.Default); err != nil {
+		color.PrintFatal(err)
+	}
+	peers, err := pController.GetProfileContainers("")
+	if err != nil {
+		color.PrintFatal(err)
+	}
+
+	//the containers of every profile bind host ports
+	conSlice, err := pController.GetAllContainers()
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	opt := containers.ServiceOptions{
+		Image:     addImage,
+		Network:   network,
+		Peers:     peers,
+		Storage:   projectStorage(peers),
+		User:      databaseUser(),
+		UsedPorts: usedPorts(utils.GetAbsChild("."), conSlice),
+	}
+	if storage != "" {
+		if opt.Storage, err = containers.ParseStorage(storage); err != nil {
+			color.PrintFatal(err)
+		}
+	}
+	if s.defaultImg != "" {
+		if addImage != "" {
+			serviceImages = map[string]string{s.name: addImage}
+		}
+		opt.Image = chooseImage(ctx, cController, s.name, s.defaultImg)
+		if err := validateImage(ctx, cController, policy, opt.Image); err != nil {
+			color.PrintFatal(err)
+		}
+		pullImages(ctx, cController, policy, []*containers.Image{{Name: opt.Image}}, func(err error) {
+			color.PrintFatal(err)
+		})
+	}
+
+	var creater containers.ContainerCreator
+	handleCreation := creationHandler(pController, cController, &creater, func() {})
+	if creater, err = s.setup(projectName, opt, handleCreation); err != nil {
+		color.PrintFatal(err)
+	}
+	if s.defaultImg == "" {
+		if err := prepareImage(ctx, cController, policy, creater.GetContainer(ctx)); err != nil {
+			color.PrintFatal(err)
+		}
+	}
+	if err := replaceStaleDataDirs(creater.GetContainer(ctx)); err != nil {
+		color.PrintFatal(err)
+	}
+	if err := ensureVolumes(ctx, cController, creater.GetContainer(ctx)); err != nil {
+		color.PrintFatal(err)
+	}
+	cController.Start(ctx, creater).Wait()
+	registerProject(pController)
+	color.PrintStatus("Service Added", fmt.Sprintf("%s run blah project --start to start it.", s.label))
+}
+
+// Removes the container of a service, its .env keys and unless keepData is set its data directories and volumes
+func removeService(ctx context.Context, name string) {
+	s := findService(name)
+	pController, cController := loadProject(ctx)
+	projectName := currentProjectName()
+
+	c, err := pController.FindContainerByService("", s.name, utils.PrefixProjectName(projectName, s.name))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			color.PrintFatal(fmt.Errorf("%s is not part of project %s", s.name, projectName))
+		}
+		color.PrintFatal(err)
+	}
+
+	//the containers of the service in other profiles are removed as well
+	removed, err := profileContainers(pController, projectName, s.name)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	removed = append([]*containers.Container{c}, removed...)
+
+	var dataDirs, volumes []string
+	for i := range removed {
+		for _, m := range serviceDataMounts(removed[i]) {
+			if m.Type == mount.TypeVolume {
+				volumes = append(volumes, m.Source)
+			} else {
+				dataDirs = append(dataDirs, m.Source)
+			}
+		}
+	}
+	removeData := !keepData && len(dataDirs)+len(volumes) > 0
+	if removeData && !assumeYes {
+		reader := bufio.NewReader(os.Stdin)
+		color.PrintYellow(fmt.Sprintf("The data of %s in %s will be deleted.", s.label, strings.Join(append(dataDirs, volumes...), ", ")))
+		removeData, err = utils.GetConfirmation(reader, "Delete the data? (y/n): ")
+		if err != nil {
+			color.PrintFatal(err)
+		}
+	}
+
+	for _, c := range removed {
+		opt := containers.CRMOptions{Force: true, RemoveVolumes: true}
+		remover := containers.NewRemoveContainerPayload(c.ContainerID, opt, func(ctx context.Context, err error) error {
+			//the container may already have been removed outside of blah
+			if err != nil && !errdefs.IsNotFound(err) {
+				return err
+			}
+			return pController.DeleteContainer(c)
+		})
+		cController.Start(ctx, remover).Wait()
+
+		//the env of the app is a copy of the .env, its keys belong to the other services
+		if s.name != appService.name {
+			keys := make([]string, len(c.Env))
+			for i := range c.Env {
+				keys[i] = c.Env[i].Key
+			}
+			if err := envfile.Remove(profile.EnvFile(c.Profile), keys...); err != nil {
+				color.PrintFatal(err)
+			}
+		}
+	}
+
+	if removeData {
+		for i := range dataDirs {
+			if err := os.RemoveAll(dataDirs[i]); err != nil {
+				color.PrintFatal(err)
+			}
+		}
+		//the volumes can only be removed once the containers using them are gone
+		if err := removeVolumes(ctx, cController, volumes); err != nil {
+			color.PrintFatal(err)
+		}
+	}
+	registerProject(pController)
+	color.PrintStatus("Service Removed", s.label)
+}
+
+// Returns the containers of a service in the profiles other than the default profile
+func profileContainers(pController *persistence.PersistedDataController, projectName string, service string) ([]*containers.Container, error) {
+	profiles, err := pController.GetAllProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var conSlice []*containers.Container
+	for i := range profiles {
+		c, err := pController.FindContainerByService(profiles[i].Name, service, profile.ContainerName(projectName, profiles[i].Name, service))
+		if err == nil {
+			conSlice = append(conSlice, c)
+		} else if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+	return conSlice, nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/profile"
+)
+
+// Returns the network the containers of the default profile join EG. myproj_default
+func projectNetwork(projectName string) string {
+	return profile.Network(projectName, profile.Default)
+}
+
+// Creates the network of a profile of the project, an existing network is reused
+func ensureNetwork(ctx context.Context, cController *containers.Controller, projectName string, profileName string) error {
+	var networkErr error
+	name := profile.Network(projectName, profileName)
+	labels := containers.Labels(projectName, "", profile.Stored(profileName))
+	creator := containers.NewNetworkCreatePayload(name, labels, func(ctx context.Context, err error) error {
+		if !containers.IsErrNetworkExists(err) {
+			networkErr = err
+		}
+		return nil
+	})
+	cController.Start(ctx, creator).Wait()
+	return networkErr
+}
+
+// Builds the image of a container that has a build context, otherwise its image is validated and pulled.
+// Used for services like the app whose image is only known after their setup ran
+func prepareImage(ctx context.Context, cController *containers.Controller, policy containers.PullPolicy, c containers.Container) error {
+	if c.BuildContext != "" {
+		return buildImage(ctx, cController, c)
+	}
+	if err := validateImage(ctx, cController, policy, c.Image); err != nil {
+		return err
+	}
+	var pullErr error
+	pullImages(ctx, cController, policy, []*containers.Image{{Name: c.Image}}, func(err error) {
+		pullErr = err
+	})
+	return pullErr
+}
+
+// Builds the Dockerfile in the build context of the container and tags it with the container image
+func buildImage(ctx context.Context, cController *containers.Controller, c containers.Container) error {
+	var buildErr error
+	options := containers.BuildOptions{ContextDir: c.BuildContext, Tag: c.Image}
+	builder := containers.NewImageBuildPayload(options, os.Stdout, func(ctx context.Context, err error) error {
+		buildErr = err
+		return nil
+	})
+	cController.Start(ctx, builder).Wait()
+	return buildErr
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Change the settings of the project services",
+	}
+	configSetCmd = &cobra.Command{
+		Use:   "set <service>.<setting>=<value>...",
+		Short: "Set the limits or restart policy of a service, its containers are recreated with the new settings",
+		Long: "Settings:\n" +
+			"  memory   memory limit EG. 512m or 1g, 0 removes the limit\n" +
+			"  cpus     number of CPUs EG. 0.5 or 2, 0 removes the limit\n" +
+			"  restart  restart policy no|on-failure|unless-stopped|always, unless-stopped keeps\n" +
+			"           containers started with blah start --background up across docker daemon restarts",
+		Example: "blah config set mysql.memory=512m mysql.cpus=0.5\n" +
+			"blah config set mongodb.restart=unless-stopped",
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			setServiceConfig(context.Background(), args)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configSetCmd)
+}
+
+// Applies the settings to the containers of their services in every profile, the restart policy is saved
+// with the container when it is recreated. Each container is recreated once after all of its settings were applied.
+// Every setting is validated and every container looked up before the first one is recreated so an invalid
+// setting does not leave the change half applied
+func setServiceConfig(ctx context.Context, args []string) {
+	pController, cController := loadProject(ctx)
+	projectName := currentProjectName()
+
+	var order []string
+	settings := make(map[string][][2]string)
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		name, setting, hasSetting := strings.Cut(key, ".")
+		if !ok || !hasSetting {
+			color.PrintFatal(fmt.Errorf("Invalid setting %q should look like <service>.<setting>=<value> EG. mysql.memory=512m", arg))
+		}
+		s := findService(name)
+		if err := applySetting(&containers.Container{}, setting, value); err != nil {
+			color.PrintFatal(err)
+		}
+		if _, ok := settings[s.name]; !ok {
+			order = append(order, s.name)
+		}
+		settings[s.name] = append(settings[s.name], [2]string{setting, value})
+	}
+
+	serviceContainers := make(map[string][]*containers.Container)
+	for _, name := range order {
+		c, err := pController.FindContainerByService("", name, utils.PrefixProjectName(projectName, name))
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				color.PrintFatal(fmt.Errorf("%s is not part of project %s", name, projectName))
+			}
+			color.PrintFatal(err)
+		}
+		others, err := profileContainers(pController, projectName, name)
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		serviceContainers[name] = append([]*containers.Container{c}, others...)
+	}
+
+	for _, name := range order {
+		for _, c := range serviceContainers[name] {
+			for _, setting := range settings[name] {
+				if err := applySetting(c, setting[0], setting[1]); err != nil {
+					color.PrintFatal(err)
+				}
+			}
+			//associations are only inserted when the container is saved, updates are saved directly
+			c.Resources.ResourcesRefer = c.ID
+			if err := pController.Persist(&c.Resources); err != nil {
+				color.PrintFatal(err)
+			}
+			recreateContainer(ctx, pController, cController, c)
+			color.PrintStatus("Config", fmt.Sprintf("Recreated %s with %s restart=%s", c.Name, c.Resources, c.CreateRestartPolicy().Name))
+		}
+	}
+	color.PrintYellow("A running project has to be started again to use the new settings.")
+}
+
+// Sets a single setting of a container EG. memory=512m
+func applySetting(c *containers.Container, setting string, value string) error {
+	var err error
+	switch setting {
+	case "memory":
+		c.Resources.Memory, err = containers.ParseMemory(value)
+	case "cpus":
+		c.Resources.NanoCPUs, err = containers.ParseCPUs(value)
+	case "restart":
+		c.Restart, err = containers.ParseRestartPolicy(value)
+	default:
+		err = fmt.Errorf("Unknown setting %s should be one of memory, cpus, restart", setting)
+	}
+	return err
+}
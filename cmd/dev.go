@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/isolateminds/blah/internal/app"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/watcher"
+	"github.com/spf13/cobra"
+)
+
+var (
+	devIgnore   []string
+	devDebounce time.Duration
+
+	// Editor swap files, dependency and VCS directories never trigger a reload
+	defaultDevIgnore = []string{".git", "node_modules", "__pycache__", "*.swp", "*.swx", "*~", ".#*", "4913"}
+
+	devCmd = &cobra.Command{
+		Use:     "dev",
+		Short:   "Start the project and rebuild or restart the app container whenever src/ changes",
+		Example: "blah dev --ignore '*.log' --ignore tmp",
+		Run: func(cmd *cobra.Command, args []string) {
+			loadProfileEnv(profileName)
+			startProject(context.Background(), true, profileName)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(devCmd)
+	devCmd.Flags().StringSliceVar(&devIgnore, "ignore", nil, "Pattern of files or directories in src/ that do not trigger a reload EG. *.log")
+	devCmd.Flags().DurationVar(&devDebounce, "debounce", watchDebounce, "Time without changes before the app is reloaded")
+	addRunFlags(devCmd.Flags())
+}
+
+// Watches the source of the app container and streams its logs
+type appWatch struct {
+	c          *containers.Container
+	dir        string
+	w          *watcher.Watcher
+	cancelLogs context.CancelFunc
+}
+
+// Finds the app container in conSlice and watches its source directory. An app that is built from
+// a Dockerfile is rebuilt first so that it runs the current source
+func watchApp(ctx context.Context, pController *persistence.PersistedDataController, cController *containers.Controller, conSlice []*containers.Container, profileName string) *appWatch {
+	var c *containers.Container
+	name := profile.ContainerName(currentProjectName(), profileName, app.ServiceName)
+	for i := range conSlice {
+		if conSlice[i].Service == app.ServiceName || conSlice[i].Name == name {
+			c = conSlice[i]
+		}
+	}
+	if c == nil {
+		color.PrintFatal(fmt.Errorf("Project has no app service, add it with blah add app"))
+	}
+
+	dir := c.BuildContext
+	if dir == "" {
+		var ok bool
+		if dir, ok = c.HostPath(app.SourceTarget); !ok {
+			color.PrintFatal(fmt.Errorf("%s does not mount %s", c.Name, app.SourceTarget))
+		}
+	}
+	w, err := watcher.New(devDebounce)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	w.Ignore(append(defaultDevIgnore, devIgnore...)...)
+	if err := w.AddTree(dir); err != nil {
+		color.PrintFatal(err)
+	}
+
+	if c.BuildContext != "" {
+		if err := buildImage(ctx, cController, *c); err != nil {
+			color.PrintFatal(err)
+		}
+		recreateContainer(ctx, pController, cController, c)
+	}
+	color.PrintStatus("App", fmt.Sprintf("Watching %s for changes", dir))
+	return &appWatch{c: c, dir: dir, w: w}
+}
+
+// Receives a batch for every change, a nil watch never receives
+func (a *appWatch) events() <-chan []string {
+	if a == nil {
+		return nil
+	}
+	return a.w.Events()
+}
+func (a *appWatch) errors() <-chan error {
+	if a == nil {
+		return nil
+	}
+	return a.w.Errors()
+}
+
+// Rebuilds and recreates the app if it is built from a Dockerfile, otherwise restarts it so the mounted
+// source is run again. Returns true if the container was restarted
+func (a *appWatch) reload(ctx context.Context, pController *persistence.PersistedDataController, cController *containers.Controller, changed []string) bool {
+	color.PrintStatus("App", fmt.Sprintf("%s changed, reloading", describeChanges(a.dir, changed)))
+	if a.c.BuildContext != "" {
+		//the running app is kept if the new source does not build
+		if err := buildImage(ctx, cController, *a.c); err != nil {
+			color.PrintError("App", err)
+			return false
+		}
+	}
+	a.stopLogs()
+	started := time.Now()
+	if a.c.BuildContext != "" {
+		recreateContainer(ctx, pController, cController, a.c)
+		starter := containers.NewStartContainerPayload(a.c.ContainerID, func(ctx context.Context, err error) error {
+			if err != nil {
+				color.PrintError("App", err)
+			}
+			return nil
+		})
+		cController.Start(ctx, starter).Wait()
+	} else {
+		restartContainer(ctx, cController, a.c.ContainerID)
+	}
+	a.streamLogs(ctx, cController, started)
+	return true
+}
+
+// Streams the output of the app since a point in time prefixed by its service name until stopLogs is called
+func (a *appWatch) streamLogs(ctx context.Context, cController *containers.Controller, since time.Time) {
+	if a == nil {
+		return
+	}
+	ctx, a.cancelLogs = context.WithCancel(ctx)
+	options := containers.ContainerLogOptions{
+		ID:     a.c.ContainerID,
+		Follow: true,
+		Since:  fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond()),
+	}
+	prefix := fmt.Sprintf("%s | ", app.ServiceName)
+	logger := containers.NewContainerLogPayload(options, newPrefixWriter(os.Stdout, prefix), newPrefixWriter(os.Stderr, prefix), func(ctx context.Context, err error) error {
+		if err != nil {
+			color.PrintError("App", err)
+		}
+		return nil
+	})
+	cController.Start(ctx, logger)
+}
+
+func (a *appWatch) stopLogs() {
+	if a.cancelLogs != nil {
+		a.cancelLogs()
+	}
+}
+
+// EG. main.go or 3 files in src
+func describeChanges(dir string, changed []string) string {
+	if len(changed) == 1 {
+		if rel, err := filepath.Rel(dir, changed[0]); err == nil {
+			return rel
+		}
+		return changed[0]
+	}
+	return fmt.Sprintf("%d files in %s", len(changed), filepath.Base(dir))
+}
+
+// Writes every complete line with a prefix so the output of containers can be told apart from blah
+type prefixWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    bytes.Buffer
+}
+
+func newPrefixWriter(out io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{out: out, prefix: prefix}
+}
+
+func (w *prefixWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(b)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			//keeps the incomplete line until the rest of it is written
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			return len(b), nil
+		}
+		if _, err := io.WriteString(w.out, w.prefix+strings.TrimRight(line, "\r\n")+"\n"); err != nil {
+			return 0, err
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/isolateminds/blah/internal/app"
+	"github.com/isolateminds/blah/internal/codegen"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/envfile"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	codegenLang    string
+	codegenOut     string
+	codegenPackage string
+	codegenCheck   bool
+	envReveal      bool
+	envFormat      string
+	envCmd         = &cobra.Command{
+		Use:   "env",
+		Short: "Work with the environment variables blah manages for the project",
+	}
+	envShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Print the variables of the .env, secrets are masked unless --reveal is set",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			showEnv()
+		},
+	}
+	envGetCmd = &cobra.Command{
+		Use:     "get <KEY>",
+		Short:   "Print the value of a variable of the .env",
+		Example: "mongosh $(blah env get MONGODB_URL)",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			value, ok := readEnvFile(profileName).Get(args[0])
+			if !ok {
+				color.PrintFatal(fmt.Errorf("%s is not set in %s", args[0], profile.EnvFile(profileName)))
+			}
+			fmt.Println(value)
+		},
+	}
+	envExportCmd = &cobra.Command{
+		Use:     "export",
+		Short:   "Print the variables of the .env as shell exports, json or dotenv",
+		Example: "eval \"$(blah env export --format=shell)\"",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			exportEnv(envFormat)
+		},
+	}
+	envDiffCmd = &cobra.Command{
+		Use:   "diff",
+		Short: "Compare the .env, the env persisted in persist.db and the env of the containers",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			diffEnv(context.Background())
+		},
+	}
+	envCodegenCmd = &cobra.Command{
+		Use:     "codegen",
+		Short:   "Generate a typed loader for the environment variables of the project services",
+		Example: "blah env codegen --lang go --out src/config/env.go\nblah env codegen --out src/config/env.go --check",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			generateEnv(context.Background())
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(envCmd)
+	envCmd.AddCommand(envCodegenCmd)
+	envCmd.AddCommand(envShowCmd)
+	envCmd.AddCommand(envGetCmd)
+	envCmd.AddCommand(envExportCmd)
+	envCmd.AddCommand(envDiffCmd)
+	envShowCmd.Flags().BoolVar(&envReveal, "reveal", false, "Print secrets in plain text")
+	envDiffCmd.Flags().BoolVar(&envReveal, "reveal", false, "Print secrets in plain text")
+	envExportCmd.Flags().StringVar(&envFormat, "format", "shell", "Output format shell|json|dotenv")
+	envCodegenCmd.Flags().StringVar(&codegenLang, "lang", "go", "Language of the generated code")
+	envCodegenCmd.Flags().StringVar(&codegenOut, "out", filepath.Join(app.SourceDir, "config", "env.go"), "File the code is written to")
+	envCodegenCmd.Flags().StringVar(&codegenPackage, "package", "", "Package name of the generated code, defaults to the directory name of --out")
+	envCodegenCmd.Flags().BoolVar(&codegenCheck, "check", false, "Exit with an error if --out is not up to date instead of writing it")
+	for _, cmd := range []*cobra.Command{envShowCmd, envGetCmd, envExportCmd, envDiffCmd, envCodegenCmd} {
+		cmd.Flags().StringVar(&profileName, "profile", profile.Default, "Profile whose env is used EG. test for .env.test")
+	}
+}
+
+// Generates the env loader from the env persisted for every container, with --check the existing file is compared
+func generateEnv(ctx context.Context) {
+	if err := codegen.Validate(codegenLang); err != nil {
+		color.PrintFatal(err)
+	}
+	pController, _ := loadProject(ctx)
+	vars, err := persistedEnvVars(pController, profileName)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	pkg := codegenPackage
+	if pkg == "" {
+		abs, err := filepath.Abs(codegenOut)
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		pkg = filepath.Base(filepath.Dir(abs))
+	}
+	source, err := codegen.Env(codegenLang, pkg, vars)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+
+	if codegenCheck {
+		current, err := ioutil.ReadFile(codegenOut)
+		if err != nil && !os.IsNotExist(err) {
+			color.PrintFatal(err)
+		}
+		if !bytes.Equal(current, source) {
+			color.PrintFatal(fmt.Errorf("%s is stale, run blah env codegen --out %s", codegenOut, codegenOut))
+		}
+		color.PrintStatus("Env", fmt.Sprintf("%s is up to date", codegenOut))
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(codegenOut), 0755); err != nil {
+		color.PrintFatal(err)
+	}
+	if err := ioutil.WriteFile(codegenOut, source, 0644); err != nil {
+		color.PrintFatal(err)
+	}
+	color.PrintStatus("Env", fmt.Sprintf("Generated %s", codegenOut))
+}
+
+// Returns the env keys of every container of a profile. The env of the app is copied from the .env,
+// keys that only the app has are set inside its container and are optional
+func persistedEnvVars(pController *persistence.PersistedDataController, profileName string) ([]codegen.EnvVar, error) {
+	if err := profile.Validate(profileName); err != nil {
+		return nil, err
+	}
+	conSlice, err := pController.GetProfileContainers(profile.Stored(profileName))
+	if err != nil {
+		return nil, err
+	}
+	var vars []codegen.EnvVar
+	for _, c := range conSlice {
+		for i := range c.Env {
+			vars = append(vars, codegen.EnvVar{Key: c.Env[i].Key, Required: c.Service != app.ServiceName})
+		}
+	}
+	return vars, nil
+}
+
+// Reads the env file of a profile of the project in the working directory EG. .env or .env.test
+func readEnvFile(profileName string) *envfile.File {
+	if err := profile.Validate(profileName); err != nil {
+		color.PrintFatal(err)
+	}
+	path := profile.EnvFile(profileName)
+	if !utils.FileExists(path) {
+		if profile.Stored(profileName) != "" {
+			color.PrintFatal(fmt.Errorf("Could not find %s, it is created by blah start --profile %s", path, profileName))
+		}
+		color.PrintFatal(fmt.Errorf("Could not find .env file are you in project (root) directory?"))
+	}
+	f, err := envfile.Read(path)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	return f
+}
+
+// Masks the value unless --reveal is set
+func displayEnv(key string, value string) string {
+	if envReveal {
+		return value
+	}
+	return envfile.Mask(key, value)
+}
+
+func showEnv() {
+	f := readEnvFile(profileName)
+	for _, key := range f.Keys() {
+		value, _ := f.Get(key)
+		fmt.Printf("%s=%s\n", key, displayEnv(key, value))
+	}
+}
+
+func exportEnv(format string) {
+	f := readEnvFile(profileName)
+	switch format {
+	case "shell":
+		for _, key := range f.Keys() {
+			value, _ := f.Get(key)
+			fmt.Printf("export %s='%s'\n", key, strings.ReplaceAll(value, "'", `'\''`))
+		}
+	case "json":
+		b, err := json.MarshalIndent(f.Map(), "", "  ")
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		fmt.Println(string(b))
+	case "dotenv":
+		for _, key := range f.Keys() {
+			value, _ := f.Get(key)
+			fmt.Printf("%s=%s\n", key, envfile.Quote(value))
+		}
+	default:
+		color.PrintFatal(fmt.Errorf("Unknown format %s should be one of shell, json, dotenv", format))
+	}
+}
+
+// Compares every variable persisted for a container of the profile with its env file and the env of the
+// running container. Exits with an error if a variable drifted
+func diffEnv(ctx context.Context) {
+	pController, cController := loadProject(ctx)
+	f := readEnvFile(profileName)
+	envPath := profile.EnvFile(profileName)
+	conSlice, err := pController.GetProfileContainers(profile.Stored(profileName))
+	if err != nil {
+		color.PrintFatal(err)
+	}
+
+	drifted := 0
+	for _, c := range conSlice {
+		live, err := inspectContainerEnv(ctx, cController, c.ContainerID)
+		if err != nil {
+			if !containers.IsErrContainerNotFound(err) {
+				color.PrintFatal(err)
+			}
+			color.PrintYellow(fmt.Sprintf("%s does not exist, it was removed outside of blah", c.Name))
+		}
+		var lines []string
+		for _, e := range c.Env {
+			values := map[string]string{"persist.db": e.Value}
+			//the env of the app is rewritten for the project network so it never equals the .env
+			if c.Service != app.ServiceName {
+				values[envPath] = envValue(f.Get(e.Key))
+			}
+			if live != nil {
+				value, ok := live[e.Key]
+				values["container"] = envValue(value, ok)
+			}
+			if !envDrifted(values) {
+				continue
+			}
+			drifted++
+			lines = append(lines, fmt.Sprintf("  %s", e.Key))
+			for _, source := range []string{envPath, "persist.db", "container"} {
+				if value, ok := values[source]; ok {
+					if value != envNotSet {
+						value = displayEnv(e.Key, value)
+					}
+					lines = append(lines, fmt.Sprintf("    %-11s %s", source, value))
+				}
+			}
+		}
+		if len(lines) > 0 {
+			color.PrintYellow(c.Name)
+			fmt.Println(strings.Join(lines, "\n"))
+		}
+	}
+	if drifted > 0 {
+		color.PrintFatal(fmt.Errorf("%d variables differ, recreate the container or update the .env", drifted))
+	}
+	color.PrintStatus("Env", fmt.Sprintf("The %s, persist.db and the containers agree", envPath))
+}
+
+// Inspects a container and returns its environment
+func inspectContainerEnv(ctx context.Context, cController *containers.Controller, ID string) (map[string]string, error) {
+	var (
+		env        map[string]string
+		inspectErr error
+	)
+	inspector := containers.NewContainerInspectPayload(ID, func(ctx context.Context, err error) error {
+		if err != nil {
+			inspectErr = err
+			return nil
+		}
+		if inspect, ok := containers.FromContainerInspectContext(ctx); ok {
+			env = containers.InspectEnv(inspect)
+		}
+		return nil
+	})
+	cController.Start(ctx, inspector).Wait()
+	return env, inspectErr
+}
+
+// Marks a variable that is not set so it differs from an empty value
+const envNotSet = "(not set)"
+
+func envValue(value string, ok bool) string {
+	if !ok {
+		return envNotSet
+	}
+	return value
+}
+
+func envDrifted(values map[string]string) bool {
+	first := values["persist.db"]
+	for _, value := range values {
+		if value != first {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+)
+
+const ephemeralSuffix = "ephemeral"
+
+// Replaces the containers that keep data in the database directory or a volume with throwaway copies whose data
+// lives on tmpfs. The persisted containers are stopped, the copies are not persisted and docker removes them when they are stopped
+func ephemeralContainers(ctx context.Context, cController *containers.Controller, conSlice []*containers.Container) []*containers.Container {
+	result := make([]*containers.Container, len(conSlice))
+	for i, c := range conSlice {
+		if len(serviceDataMounts(c)) == 0 {
+			result[i] = c
+			continue
+		}
+		eph := ephemeralCopy(c)
+
+		//the copy binds the same host ports, the persisted container may still run from blah start --background
+		stopper := containers.NewContainerStopperPayload(&c.ContainerID, func(ctx context.Context, err error) error {
+			if err != nil && !errdefs.IsNotFound(err) {
+				return err
+			}
+			return nil
+		})
+		cController.Start(ctx, stopper).Wait()
+
+		//a copy left behind by a run that was killed is removed first
+		opt := containers.CRMOptions{Force: true, RemoveVolumes: true}
+		remover := containers.NewRemoveContainerPayload(eph.Name, opt, func(ctx context.Context, err error) error {
+			return nil
+		})
+		cController.Start(ctx, remover).Wait()
+
+		creater := containers.NewCreateContainerPayload(&eph, func(ctx context.Context, err error) error {
+			if err != nil {
+				return err
+			}
+			if created, ok := containers.FromContainerContext(ctx); ok {
+				eph = *created
+			}
+			return nil
+		})
+		cController.Start(ctx, creater).Wait()
+		color.PrintStatus("Ephemeral", fmt.Sprintf("%s keeps its data in memory", eph.Name))
+		result[i] = &eph
+	}
+	return result
+}
+
+// Copies a container with its data directories and volumes mounted as tmpfs EG. myproj_mongodb_ephemeral
+func ephemeralCopy(c *containers.Container) containers.Container {
+	dataMounts := make(map[string]bool)
+	for _, m := range serviceDataMounts(c) {
+		dataMounts[m.Source] = true
+	}
+	eph := containers.Container{
+		Name:         fmt.Sprintf("%s_%s", c.Name, ephemeralSuffix),
+		Project:      c.Project,
+		Service:      c.Service,
+		Profile:      c.Profile,
+		Image:        c.Image,
+		ImageDigest:  c.ImageDigest,
+		Hostname:     c.Hostname,
+		Network:      c.Network,
+		WorkingDir:   c.WorkingDir,
+		BuildContext: c.BuildContext,
+		AutoRemove:   true,
+		User:         c.User,
+		Resources:    containers.ContainerResources{Memory: c.Resources.Memory, NanoCPUs: c.Resources.NanoCPUs},
+		ExposedPorts: c.ExposedPorts,
+		PortBindings: c.PortBindings,
+		Env:          c.Env,
+		Cmd:          c.Cmd,
+		ExtraHosts:   c.ExtraHosts,
+	}
+	for _, m := range c.Mounts {
+		if dataMounts[m.Source] {
+			m = containers.ContainerMount{Type: mount.TypeTmpfs, Tagret: m.Tagret}
+		}
+		eph.Mounts = append(eph.Mounts, m)
+	}
+	return eph
+}
@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/isolateminds/blah/internal/app"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/mongodb"
+	"github.com/isolateminds/blah/internal/mysql"
+	"github.com/isolateminds/blah/internal/nginx"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	assumeYes bool
+	imagesCmd = &cobra.Command{
+		Use:   "images",
+		Short: "Manage the images of a project",
+	}
+	imagesSaveCmd = &cobra.Command{
+		Use:     "save <bundle.tar>",
+		Short:   "Save every image the project needs into a bundle for offline use",
+		Example: "blah images save myproj-images.tar",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			saveImageBundle(context.Background(), args[0])
+		},
+	}
+	imagesLoadCmd = &cobra.Command{
+		Use:     "load <bundle.tar>",
+		Short:   "Load the images of a bundle created with blah images save",
+		Example: "blah images load myproj-images.tar",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			if err := loadImageBundle(ctx, connectEngine(ctx), args[0]); err != nil {
+				color.PrintFatal(err)
+			}
+		},
+	}
+	imagesUpgradeCmd = &cobra.Command{
+		Use:     "upgrade [container...]",
+		Short:   "Pull the newest image of each container and recreate it with the new digest",
+		Example: "blah images upgrade myproj_mongodb\nblah images upgrade --profile test",
+		Run: func(cmd *cobra.Command, args []string) {
+			upgradeImages(context.Background(), args)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(imagesCmd)
+	imagesCmd.AddCommand(imagesSaveCmd)
+	imagesCmd.AddCommand(imagesLoadCmd)
+	imagesCmd.AddCommand(imagesUpgradeCmd)
+	imagesUpgradeCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Upgrade without asking when the major version changes")
+	imagesUpgradeCmd.Flags().StringVar(&profileName, "profile", profile.Default, "Profile whose containers are upgraded EG. test")
+}
+
+// Writes the images of every container in the project into a tar bundle
+func saveImageBundle(ctx context.Context, bundlePath string) {
+	pController, cController := loadProject(ctx)
+	conSlice, err := pController.GetAllContainers()
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	//images are saved by tag, digest references lose their name when they are loaded again. The tag
+	//has to point to the image the project is pinned to EG. after a docker pull it may not
+	var images []containers.Image
+	saved := make(map[string]bool)
+	for i := range conSlice {
+		if err := verifyPinnedImage(ctx, cController, conSlice[i]); err != nil {
+			color.PrintFatal(err)
+		}
+		if !saved[conSlice[i].Image] {
+			saved[conSlice[i].Image] = true
+			images = append(images, containers.Image{Name: conSlice[i].Image})
+		}
+	}
+
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	defer f.Close()
+	for i := range images {
+		color.PrintStatus("Saving", images[i].Name)
+	}
+	saver := containers.NewImageSavePayload(images, f, func(ctx context.Context, err error) error {
+		if err != nil {
+			os.Remove(bundlePath)
+		}
+		return err
+	})
+	cController.Start(ctx, saver).Wait()
+	color.PrintStatus("Saved", bundlePath)
+}
+
+// Returns an error if the local image of the tag a container uses is not the image it is pinned to.
+// Images that were built locally are not pinned
+func verifyPinnedImage(ctx context.Context, cController *containers.Controller, c *containers.Container) error {
+	if c.ImageDigest == "" {
+		return nil
+	}
+	inspect, err := inspectImage(ctx, cController, c.Image)
+	if err != nil {
+		return err
+	}
+	digest, err := containers.ImageRepoDigest(inspect, c.Image)
+	if err != nil {
+		return err
+	}
+	if digest != c.ImageDigest {
+		return fmt.Errorf("%s is pinned to %s but the local %s is a different image, run docker pull %s && docker tag %s %s or blah images upgrade first",
+			c.Name, c.ImageDigest, c.Image, c.ImageRef(), c.ImageRef(), c.Image)
+	}
+	return nil
+}
+
+// Loads the images of a bundle so that a project can be initialized without network access
+func loadImageBundle(ctx context.Context, cController *containers.Controller, bundlePath string) error {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var loadErr error
+	progress := containers.NewPullProgress(os.Stdout)
+	loader := containers.NewImageLoadPayload(f, progress.Writer(path.Base(bundlePath)), func(ctx context.Context, err error) error {
+		loadErr = err
+		return nil
+	})
+	cController.Start(ctx, loader).Wait()
+	progress.Flush()
+	return loadErr
+}
+
+// Moves the containers of the profile to the newest digest of their image reference
+func upgradeImages(ctx context.Context, names []string) {
+	pController, cController := loadProject(ctx)
+	conSlice, err := pController.GetProfileContainers(profile.Stored(profileName))
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	conSlice = filterContainers(conSlice, names)
+	//images built from a Dockerfile have no registry to pull a newer version from
+	var pullable []*containers.Container
+	for i := range conSlice {
+		if conSlice[i].BuildContext != "" {
+			color.PrintStatus(conSlice[i].Name, "Skipped, the image is built from a Dockerfile")
+			continue
+		}
+		pullable = append(pullable, conSlice[i])
+	}
+	conSlice = pullable
+	if len(conSlice) == 0 {
+		color.PrintYellow("No containers to upgrade")
+		return
+	}
+
+	var images []*containers.Image
+	pulled := make(map[string]bool)
+	for i := range conSlice {
+		if !pulled[conSlice[i].Image] {
+			pulled[conSlice[i].Image] = true
+			images = append(images, &containers.Image{Name: conSlice[i].Image})
+		}
+	}
+	pullImages(ctx, cController, containers.PullAlways, images, func(err error) { color.PrintFatal(err) })
+
+	for i := range conSlice {
+		c := conSlice[i]
+		latest, err := inspectImage(ctx, cController, c.Image)
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		digest, err := containers.ImageRepoDigest(latest, c.Image)
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		if digest == c.ImageDigest {
+			color.PrintStatus(c.Name, fmt.Sprintf("Already up to date %s", c.ImageRef()))
+			continue
+		}
+		if !confirmMajorUpgrade(ctx, cController, c, latest) {
+			color.PrintStatus(c.Name, "Skipped")
+			continue
+		}
+		c.ImageDigest = digest
+		recreateContainer(ctx, pController, cController, c)
+		color.PrintStatus(c.Name, fmt.Sprintf("Upgraded to %s", c.ImageRef()))
+	}
+}
+
+// Warns when the major version of the pinned image differs from the newest one and asks to continue
+func confirmMajorUpgrade(ctx context.Context, cController *containers.Controller, c *containers.Container, latest *types.ImageInspect) bool {
+	newVersion := containers.ImageVersion(c.Image, latest)
+	oldVersion := "unknown"
+	if current, err := inspectImage(ctx, cController, c.ImageRef()); err == nil {
+		oldVersion = containers.ImageVersion(c.Image, current)
+	}
+	if containers.MajorVersion(oldVersion) == containers.MajorVersion(newVersion) {
+		return true
+	}
+	color.PrintYellow(fmt.Sprintf(
+		"%s: major version changes from %s to %s, existing data files may not be compatible.",
+		c.Name, oldVersion, newVersion,
+	))
+	if assumeYes {
+		return true
+	}
+	reader := bufio.NewReader(os.Stdin)
+	upgrade, err := utils.GetConfirmation(reader, "Upgrade anyway? (y/n): ")
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	return upgrade
+}
+
+// Removes the container and creates it again from its persisted configuration. The env of the app is built
+// again from the env file of its profile so services added since it was created are reachable
+func recreateContainer(ctx context.Context, pController *persistence.PersistedDataController, cController *containers.Controller, c *containers.Container) {
+	var env []containers.ContainerEnv
+	if c.Service == app.ServiceName {
+		var err error
+		if env, err = appEnv(pController, c); err != nil {
+			color.PrintError("App", err)
+		}
+	}
+	opt := containers.CRMOptions{Force: true}
+	remover := containers.NewRemoveContainerPayload(c.ContainerID, opt, func(ctx context.Context, err error) error {
+		//the container may already have been removed outside of blah
+		if errdefs.IsNotFound(err) {
+			return nil
+		}
+		return err
+	})
+	cController.Start(ctx, remover).Wait()
+
+	//the persisted env is only replaced once the container with the new env exists
+	if env != nil {
+		c.Env = env
+	}
+	creater := containers.NewCreateContainerPayload(c, func(ctx context.Context, err error) error {
+		if err != nil {
+			return err
+		}
+		if container, ok := containers.FromContainerContext(ctx); ok {
+			if env != nil {
+				if err := pController.DeleteContainerEnv(c); err != nil {
+					return err
+				}
+			}
+			*c = *container
+			return pController.Persist(container)
+		}
+		return nil
+	})
+	cController.Start(ctx, creater).Wait()
+}
+
+// Returns the env of the app built from the env file of its profile and the containers of the profile
+func appEnv(pController *persistence.PersistedDataController, c *containers.Container) ([]containers.ContainerEnv, error) {
+	peers, err := pController.GetProfileContainers(c.Profile)
+	if err != nil {
+		return nil, err
+	}
+	return app.Env(profile.EnvFile(c.Profile), peers)
+}
+
+// Inspects a local image and waits for the result
+func inspectImage(ctx context.Context, cController *containers.Controller, name string) (*types.ImageInspect, error) {
+	var (
+		inspect    *types.ImageInspect
+		inspectErr error
+	)
+	inspector := containers.NewImageInspectPayload(name, func(ctx context.Context, err error) error {
+		if err != nil {
+			inspectErr = err
+			return nil
+		}
+		inspect, _ = containers.FromImageInspectContext(ctx)
+		return nil
+	})
+	cController.Start(ctx, inspector).Wait()
+	return inspect, inspectErr
+}
+
+// Keeps the containers whose name matches one of names, all containers are kept if names is empty
+func filterContainers(conSlice []*containers.Container, names []string) []*containers.Container {
+	if len(names) == 0 {
+		return conSlice
+	}
+	var filtered []*containers.Container
+	for i := range conSlice {
+		for j := range names {
+			if conSlice[i].Name == names[j] {
+				filtered = append(filtered, conSlice[i])
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// Returns the image chosen for a service with --<service>-image or --image service=ref,
+// the user picks a version interactively if no image was given on the command line
+func chooseImage(ctx context.Context, cController *containers.Controller, service string, defaultImg string) string {
+	if image, ok := serviceImages[service]; ok {
+		return image
+	}
+	return promptImage(ctx, cController, service, defaultImg)
+}
+
+// Prompts the user to pick one of the locally available tags of the default image repository or type another one
+func promptImage(ctx context.Context, cController *containers.Controller, service string, defaultImg string) string {
+	repository, err := containers.ImageRepository(defaultImg)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	var tags []string
+	lister := containers.NewImageListPayload(repository, func(ctx context.Context, err error) error {
+		if err != nil {
+			return err
+		}
+		tags, _ = containers.FromImageListContext(ctx)
+		return nil
+	})
+	cController.Start(ctx, lister).Wait()
+
+	output := fmt.Sprintf("Select an image for %s\n", service)
+	for i := range tags {
+		output += fmt.Sprintf("(%d) %s:%s\n", i+1, repository, tags[i])
+	}
+	output += fmt.Sprintf("Enter a number, a tag or press enter for %s\n: ", defaultImg)
+	reader := bufio.NewReader(os.Stdin)
+	input, err := utils.GetRawInput(reader, output)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+
+	image := defaultImg
+	if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(tags) {
+		image = fmt.Sprintf("%s:%s", repository, tags[n-1])
+	} else if strings.ContainsAny(input, ":@/") {
+		image = input
+	} else if input != "" {
+		image = fmt.Sprintf("%s:%s", repository, input)
+	}
+	if _, err := containers.ImageRepository(image); err != nil {
+		color.PrintYellow(fmt.Sprintf("%s is not a valid image reference.", image))
+		return promptImage(ctx, cController, service, defaultImg)
+	}
+	return image
+}
+
+// Makes sure an image exists locally or in its registry so that the pull does not fail halfway through init
+func validateImage(ctx context.Context, cController *containers.Controller, policy containers.PullPolicy, image string) error {
+	if _, err := inspectImage(ctx, cController, image); err == nil {
+		return nil
+	} else if !containers.IsErrImageNotFound(err) {
+		return err
+	}
+	if policy == containers.PullNever {
+		return fmt.Errorf("Image %s is not present locally and the pull policy is %s", image, policy)
+	}
+	var validateErr error
+	inspector := containers.NewDistributionInspectPayload(image, func(ctx context.Context, err error) error {
+		if containers.IsErrImageNotFound(err) {
+			validateErr = fmt.Errorf("Image %s does not exist, check the tag is spelled correctly", image)
+			return nil
+		}
+		if containers.IsErrImageUnauthorized(err) {
+			validateErr = fmt.Errorf("Access to image %s was denied, log in with docker login if it is private or check the name is spelled correctly: %w", image, err)
+			return nil
+		}
+		validateErr = err
+		return nil
+	})
+	cController.Start(ctx, inspector).Wait()
+	return validateErr
+}
+
+// Merges the --<service>-image flags into the --image flag and checks every reference is valid
+func parseServiceImages(services ...string) error {
+	if serviceImages == nil {
+		serviceImages = make(map[string]string)
+	}
+	//--image accepts the flag spelling of a service as well EG. mongo=mongo:6.0
+	for alias, service := range map[string]string{"mongo": mongodb.ServiceName} {
+		if image, ok := serviceImages[alias]; ok {
+			delete(serviceImages, alias)
+			if _, set := serviceImages[service]; !set {
+				serviceImages[service] = image
+			}
+		}
+	}
+	for service, image := range map[string]string{
+		mongodb.ServiceName: mongoImage,
+		mysql.ServiceName:   mysqlImage,
+		nginx.ServiceName:   nginxImage,
+	} {
+		if image != "" {
+			serviceImages[service] = image
+		}
+	}
+	for service, image := range serviceImages {
+		known := false
+		for i := range services {
+			known = known || services[i] == service
+		}
+		if !known {
+			return fmt.Errorf("Unknown service %s should be one of %s", service, strings.Join(services, ", "))
+		}
+		if _, err := containers.ImageRepository(image); err != nil {
+			return fmt.Errorf("Invalid image %s for %s: %w", image, service, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:     "init /path/to/project",
+	Short:   "Create a new project, same as blah project --init",
+	Example: "blah init myproj --images-bundle images.tar",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setupProject(context.Background(), args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+	addInitFlags(initCmd.Flags())
+}
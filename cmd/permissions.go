@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/errdefs"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runAsHostUser     bool
+	fixPermissionsCmd = &cobra.Command{
+		Use:   "fix-permissions",
+		Short: "Give the files in database/ back to your user with a throwaway helper container",
+		Long: "Databases that do not run as your user (see --host-user) own the files they write to database/, " +
+			"fix-permissions changes the owner back so the project can be deleted or backed up without sudo.\n" +
+			"Those databases take the files over again the next time they start.",
+		Example: "blah fix-permissions",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fixPermissions(context.Background())
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(fixPermissionsCmd)
+}
+
+// Returns the uid:gid of the user running blah EG. 1000:1000, empty on platforms without uids.
+// Under sudo the user that invoked sudo is returned
+func hostUser() string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 && os.Getenv("SUDO_UID") != "" {
+		return fmt.Sprintf("%s:%s", os.Getenv("SUDO_UID"), os.Getenv("SUDO_GID"))
+	}
+	return fmt.Sprintf("%d:%d", uid, gid)
+}
+
+// Returns the user the databases run as for the --host-user flag
+func databaseUser() string {
+	if !runAsHostUser {
+		return ""
+	}
+	user := hostUser()
+	if user == "" {
+		color.PrintYellow(fmt.Sprintf("--host-user is ignored on %s, docker does not change the owner of bind mounted files there", runtime.GOOS))
+	}
+	return user
+}
+
+// Changes the owner of the project database directory to the host user. The directory is bind mounted
+// into a helper container that runs chown as root, it uses the image of a database so nothing is pulled
+func fixPermissions(ctx context.Context) {
+	pController, cController := loadProject(ctx)
+	user := hostUser()
+	if user == "" {
+		color.PrintFatal(fmt.Errorf("fix-permissions is not needed on %s", runtime.GOOS))
+	}
+	databasePath := utils.GetAbsChild("database")
+	if !utils.FileExists(databasePath) {
+		color.PrintFatal(fmt.Errorf("Project has no database directory"))
+	}
+	conSlice, err := pController.GetAllContainers()
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	var image *containers.Container
+	for _, c := range conSlice {
+		for _, m := range serviceDataMounts(c) {
+			if m.Type == mount.TypeBind && image == nil {
+				image = c
+			}
+		}
+	}
+	if image == nil {
+		color.PrintFatal(fmt.Errorf("Project has no database that keeps its data in %s", databasePath))
+	}
+
+	helper := containers.Container{
+		Name:        utils.PrefixProjectName(currentProjectName(), "fix_permissions"),
+		Project:     currentProjectName(),
+		Image:       image.Image,
+		ImageDigest: image.ImageDigest,
+		User:        "0:0",
+		Entrypoint:  []string{"chown", "-R", user, "/database"},
+		Mounts: []containers.ContainerMount{
+			{Type: mount.TypeBind, Source: databasePath, Tagret: "/database"},
+		},
+	}
+	if err := runHelper(ctx, cController, helper); err != nil {
+		color.PrintFatal(err)
+	}
+	color.PrintStatus("Permissions", fmt.Sprintf("%s is owned by %s", databasePath, user))
+}
+
+// Creates, runs and removes a container that is not persisted, returns an error if it exits with a non zero code
+func runHelper(ctx context.Context, cController *containers.Controller, helper containers.Container) error {
+	opt := containers.CRMOptions{Force: true}
+	//a helper left behind by a run that was interrupted is removed first
+	cController.Start(ctx, containers.NewRemoveContainerPayload(helper.Name, opt, func(ctx context.Context, err error) error {
+		return nil
+	})).Wait()
+
+	var helperErr error
+	handle := func(ctx context.Context, err error) error {
+		if helperErr == nil {
+			helperErr = err
+		}
+		return nil
+	}
+	creater := containers.NewCreateContainerPayload(&helper, func(ctx context.Context, err error) error {
+		if created, ok := containers.FromContainerContext(ctx); ok {
+			helper.ContainerID = created.ContainerID
+		}
+		return handle(ctx, err)
+	})
+	if cController.Start(ctx, creater).Wait(); helperErr != nil {
+		return helperErr
+	}
+	cController.Start(ctx, containers.NewStartContainerPayload(helper.ContainerID, handle)).Wait()
+	if helperErr == nil {
+		cController.Start(ctx, containers.NewContainerWaitPayload(helper.ContainerID, handle)).Wait()
+		//the output of the helper explains why it failed EG. the errors of nginx -t
+		if helperErr != nil {
+			var output bytes.Buffer
+			logger := containers.NewContainerLogPayload(containers.ContainerLogOptions{ID: helper.ContainerID}, &output, &output, func(ctx context.Context, err error) error {
+				return nil
+			})
+			cController.Start(ctx, logger).Wait()
+			if output.Len() > 0 {
+				helperErr = fmt.Errorf("%w\n%s", helperErr, strings.TrimSpace(output.String()))
+			}
+		}
+	}
+	cController.Start(ctx, containers.NewRemoveContainerPayload(helper.ContainerID, opt, func(ctx context.Context, err error) error {
+		if !errdefs.IsNotFound(err) {
+			return handle(ctx, err)
+		}
+		return nil
+	})).Wait()
+	return helperErr
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/isolateminds/blah/internal/app"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/envfile"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/joho/godotenv"
+	"github.com/spf13/pflag"
+	"gorm.io/gorm"
+)
+
+var (
+	profileName string
+	ephemeral   bool
+)
+
+// Adds the flags shared by the commands that run the project
+func addRunFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&profileName, "profile", profile.Default, "Profile to run EG. test, its containers are created from the dev profile the first time")
+	flags.BoolVar(&ephemeral, "ephemeral", false, "Run the databases on tmpfs in throwaway containers that are removed on stop")
+}
+
+// Loads the env file of a profile EG. .env.test into the environment of blah
+func loadProfileEnv(name string) {
+	if err := profile.Validate(name); err != nil {
+		color.PrintFatal(err)
+	}
+	if err := godotenv.Load(profile.EnvFile(profile.Default)); err != nil {
+		color.PrintFatal(fmt.Errorf("Could not load .env file are you in project (root) directory?"))
+	}
+	if name != profile.Default {
+		//variables of the profile win over the ones of the default .env
+		if env, err := godotenv.Read(profile.EnvFile(name)); err == nil {
+			for key, value := range env {
+				os.Setenv(key, value)
+			}
+		}
+	}
+}
+
+// Creates the containers of the services the profile does not have yet from the default profile.
+// The profile gets its own port offset, network, data directories and env file
+func ensureProfile(ctx context.Context, pController *persistence.PersistedDataController, cController *containers.Controller, name string) {
+	if profile.Stored(name) == "" {
+		return
+	}
+	defaults, err := pController.GetProfileContainers("")
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	p, err := pController.FindProfile(name)
+	if err == gorm.ErrRecordNotFound {
+		profiles, err := pController.GetAllProfiles()
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		p = &profile.Profile{Name: name, PortOffset: nextPortOffset(profiles, defaults)}
+		if err := pController.Persist(p); err != nil {
+			color.PrintFatal(err)
+		}
+		color.PrintStatus("Profile", fmt.Sprintf("Created %s, host ports are shifted by %d", name, p.PortOffset))
+	} else if err != nil {
+		color.PrintFatal(err)
+	}
+
+	existing, err := pController.GetProfileContainers(p.Name)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	created := make(map[string]bool)
+	for i := range existing {
+		created[existing[i].Service] = true
+	}
+
+	projectName := currentProjectName()
+	if err := ensureNetwork(ctx, cController, projectName, p.Name); err != nil {
+		color.PrintFatal(err)
+	}
+	//the env files of profiles hold secrets like the .env
+	utils.AppendMissingLines(".gitignore", ".env.*")
+	var creater containers.ContainerCreator
+	handleCreation := creationHandler(pController, cController, &creater, func() {})
+	cloned := false
+	for i := range defaults {
+		clone := p.Clone(projectName, defaults[i])
+		if created[clone.Service] {
+			continue
+		}
+		//the env of the app is a copy of the .env, its keys belong to the other services
+		if clone.Service != app.ServiceName {
+			if err := envfile.Merge(profile.EnvFile(p.Name), clone.CreateENVKeyPair()...); err != nil {
+				color.PrintFatal(err)
+			}
+		}
+		if err := ensureVolumes(ctx, cController, clone); err != nil {
+			color.PrintFatal(err)
+		}
+		creater = containers.NewCreateContainerPayload(&clone, handleCreation)
+		cController.Start(ctx, creater).Wait()
+		color.PrintStatus("Profile", fmt.Sprintf("Created %s", clone.Name))
+		cloned = true
+	}
+	//the ports of the new containers are recorded so other projects do not pick them
+	if cloned {
+		registerProject(pController)
+	}
+}
+
+// Returns the smallest port offset that no other profile uses and that does not shift the host ports
+// of the default profile onto ports of other registered projects
+func nextPortOffset(profiles []profile.Profile, defaults []*containers.Container) int {
+	used := usedPorts(utils.GetAbsChild("."), nil)
+	for {
+		offset := profile.NextPortOffset(profiles)
+		collides := false
+		for _, c := range defaults {
+			for _, binding := range c.PortBindings {
+				if n, err := strconv.Atoi(binding.HostPort); err == nil && used[strconv.Itoa(n+offset)] {
+					collides = true
+				}
+			}
+		}
+		if !collides {
+			return offset
+		}
+		profiles = append(profiles, profile.Profile{PortOffset: offset})
+	}
+}
@@ -7,28 +7,34 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/docker/docker/client"
 	"github.com/isolateminds/blah/internal/color"
 	"github.com/isolateminds/blah/internal/containers"
-	"github.com/isolateminds/blah/internal/mongodb"
-	"github.com/isolateminds/blah/internal/mysql"
-	"github.com/isolateminds/blah/internal/nginx"
 	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/scaffold"
 	"github.com/isolateminds/blah/internal/utils"
 	"github.com/joho/godotenv"
 	"github.com/spf13/cobra"
-)
-
-const (
-	MONGO_SEL = iota
-	MYSQL_SEL
+	"github.com/spf13/pflag"
 )
 
 var (
-	dir        string
-	start      bool
-	projectCmd = &cobra.Command{
+	dir           string
+	start         bool
+	pull          string
+	mongoImage    string
+	mysqlImage    string
+	nginxImage    string
+	serviceImages map[string]string
+	imagesBundle  string
+	enableTLS     bool
+	scaffoldLang  string
+	projectCmd    = &cobra.Command{
 		Use:     "project",
 		Short:   "Manage/Create a new or existing project",
 		Example: "blah project --init /path/to/project",
@@ -43,7 +49,7 @@ var (
 				if err != nil {
 					color.PrintFatal(errors.New("Could not load .env file are you in project (root) directory?"))
 				}
-				startProject(ctx)
+				startProject(ctx, false, profile.Default)
 				return
 			}
 
@@ -55,50 +61,67 @@ func init() {
 	rootCmd.AddCommand(projectCmd)
 	projectCmd.PersistentFlags().StringVar(&dir, "init", "", "/path/to/new/project")
 	projectCmd.PersistentFlags().BoolVar(&start, "start", false, "Launch a development server.")
+	addInitFlags(projectCmd.PersistentFlags())
+}
+
+// Adds the flags used when initializing a project, shared by blah init and blah project --init
+func addInitFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&pull, "pull", string(containers.PullMissing), "Image pull policy always|missing|never")
+	flags.StringVar(&mongoImage, "mongo-image", "", "MongoDB image EG. mongo:6.0")
+	flags.StringVar(&mysqlImage, "mysql-image", "", "Mysql image EG. mysql:5.7")
+	flags.StringVar(&nginxImage, "nginx-image", "", "Nginx image EG. nginx:1.23")
+	flags.StringToStringVar(&serviceImages, "image", nil, "Image of a service by service name EG. --image mysql=mysql:5.7 --image mongodb=mongo:6.0")
+	flags.BoolVar(&enableTLS, "tls", false, "Serve HTTPS from nginx with a certificate for <project>.localhost signed by a development CA")
+	flags.StringVar(&scaffoldLang, "scaffold", "", "Generate a client package for the selected services into src/ EG. --scaffold=go")
+	flags.StringVar(&storage, "storage", string(containers.StorageBind), "Keep the data of the databases in directories of database/ or in named volumes bind|volume")
+	flags.BoolVar(&runAsHostUser, "host-user", false, "Run the databases as your user so the files they write to database/ stay yours")
+	flags.StringVar(&imagesBundle, "images-bundle", "", "Load images from a bundle created with blah images save instead of pulling them")
 }
 
-func startProject(ctx context.Context) {
+// Opens the persist.db of the project in the working directory and connects to the docker engine
+func loadProject(ctx context.Context) (*persistence.PersistedDataController, *containers.Controller) {
 	if !utils.FileExists("persist.db") {
 		color.PrintFatal(fmt.Errorf("Could not find persistent database file. Are you in the project (root) directory"))
 	}
-	color.PrintStatus("Container", "Starting....")
-
 	pController, err := persistence.NewPersistedDataController("persist.db")
 	if err != nil {
 		color.PrintFatal(err)
 	}
+	return pController, connectEngine(ctx)
+}
+
+// Connects to the docker engine fatally exits if it is offline
+func connectEngine(ctx context.Context) *containers.Controller {
 	client, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
 		color.PrintFatal(err)
 	}
 	cController, err := containers.NewController(ctx, client)
-	conSlice, err := pController.GetAllContainers()
-	for i := range conSlice {
-		starter := containers.NewStartContainerPayload(conSlice[i].ContainerID, nil)
-		cController.Start(ctx, starter).Wait()
-		color.PrintStatus("Container", fmt.Sprintf("Started %s", conSlice[i].Name))
-	}
-
-	//Listen until SIGINT
-	utils.HandleSIGTERM(func() {
-		fmt.Println()
-		for i := range conSlice {
-			stopper := containers.NewContainerStopperPayload(&conSlice[i].ContainerID, nil)
-			cController.Start(ctx, stopper).Wait()
-			color.PrintStatus("Container", fmt.Sprintf("Stopped %s", conSlice[i].Name))
-		}
-
-	})
-	color.PrintForInput("Type Ctrl+C to stop running containers\n")
-
-	for {
-
+	if err != nil {
+		color.PrintFatal(err)
 	}
+	return cController
 }
 
 func setupProject(ctx context.Context, projectPath string) {
-
+	var (
+		cController *containers.Controller
+		pController *persistence.PersistedDataController
+		//volumes created by this setup, they are removed with the project if it fails
+		volumes []string
+	)
 	deleteProject := func(projectPath string) {
+		//the containers are removed first because docker keeps volumes that are in use
+		if pController != nil && cController != nil {
+			if conSlice, err := pController.GetAllContainers(); err == nil {
+				removeContainers(context.Background(), cController, conSlice, true)
+			}
+		}
+		if cController != nil {
+			if err := removeVolumes(context.Background(), cController, volumes); err != nil {
+				color.PrintError("Volume", err)
+			}
+		}
 		err := os.RemoveAll(projectPath)
 		if err != nil {
 			color.PrintFatal(err)
@@ -112,6 +135,23 @@ func setupProject(ctx context.Context, projectPath string) {
 		deleteProject(projectPath)
 	})
 
+	policy, err := containers.ParsePullPolicy(pull)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	if err := parseServiceImages(serviceNames(allServices())...); err != nil {
+		color.PrintFatal(err)
+	}
+	dataStorage, err := containers.ParseStorage(storage)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	if scaffoldLang != "" {
+		if err := scaffold.Validate(scaffoldLang); err != nil {
+			color.PrintFatal(err)
+		}
+	}
+
 	projectName := path.Base(projectPath)
 	if !utils.IsAlphaNumeric(projectName) {
 		color.PrintFatal(fmt.Errorf("Project name should be alpha numeric not %s", projectName))
@@ -128,24 +168,118 @@ func setupProject(ctx context.Context, projectPath string) {
 		deleteProject(projectPath)
 		color.PrintFatal(err)
 	}
-	cController, err := containers.NewController(context.Background(), client)
+	cController, err = containers.NewController(context.Background(), client)
 	if err != nil {
 		deleteProject(projectPath)
 		color.PrintFatal(err)
 	}
 
+	//The bundle path is relative to where blah was invoked so it is loaded before changing directory
+	if imagesBundle != "" {
+		if err := loadImageBundle(ctx, cController, imagesBundle); err != nil {
+			deleteProject(projectPath)
+			color.PrintFatal(err)
+		}
+		policy = containers.PullNever
+	}
+
 	utils.Chdir(utils.MkdirAbs(projectPath))
 	//Makes a directory for golang source code generation
 	utils.Mkdir("src")
-	pController, err := persistence.NewPersistedDataController("persist.db")
+	pController, err = persistence.NewPersistedDataController("persist.db")
+	if err != nil {
+		deleteProject(projectPath)
+		color.PrintFatal(err)
+	}
 
-	utils.AppendFileIfNotExists(".gitignore", "database/", ".env")
+	utils.AppendMissingLines(".gitignore", "database/", ".env")
 	//creating a variable here to access it in the callback function
 	var (
 		creater containers.ContainerCreator
 	)
 
-	handleCreation := func(ctx context.Context, err error) error {
+	handleCreation := creationHandler(pController, cController, &creater, func() {
+		deleteProject(projectPath)
+	})
+
+	network := projectNetwork(projectName)
+	if err := ensureNetwork(ctx, cController, projectName, profile.Default); err != nil {
+		deleteProject(projectPath)
+		color.PrintFatal(err)
+	}
+
+	//Only the images of the selected services are pulled
+	services := []service{nginxService}
+	services = append(services, promptDatabases()...)
+	if promptAddon(redisService.label) {
+		services = append(services, redisService)
+	}
+	//the source is generated before the app is set up so its container runs the generated main.go
+	if scaffoldLang != "" {
+		scaffoldSource(scaffoldLang, projectName, services)
+	}
+	//the app is set up last so it can connect to the services created before it
+	if promptAddon(appService.label) {
+		services = append(services, appService)
+	}
+	user := databaseUser()
+	//the services share the used ports so they do not pick the same free port
+	ports := usedPorts(projectPath, nil)
+	opts := make([]containers.ServiceOptions, len(services))
+	var images []*containers.Image
+	for i := range services {
+		opts[i].TLS = enableTLS
+		opts[i].Network = network
+		opts[i].Storage = dataStorage
+		opts[i].User = user
+		opts[i].UsedPorts = ports
+		//services without a default image prepare their image after their setup
+		if services[i].defaultImg == "" {
+			opts[i].Image = serviceImages[services[i].name]
+			continue
+		}
+		opts[i].Image = chooseImage(ctx, cController, services[i].name, services[i].defaultImg)
+		images = append(images, &containers.Image{Name: opts[i].Image})
+		if err := validateImage(ctx, cController, policy, opts[i].Image); err != nil {
+			deleteProject(projectPath)
+			color.PrintFatal(err)
+		}
+	}
+	pullImages(ctx, cController, policy, images, func(err error) {
+		deleteProject(projectPath)
+		color.PrintFatal(err)
+	})
+
+	for i := range services {
+		if opts[i].Peers, err = pController.GetProfileContainers(""); err != nil {
+			deleteProject(projectPath)
+			color.PrintFatal(err)
+		}
+		if creater, err = services[i].setup(projectName, opts[i], handleCreation); err != nil {
+			deleteProject(projectPath)
+			color.PrintFatal(err)
+		}
+		if services[i].defaultImg == "" {
+			if err := prepareImage(ctx, cController, policy, creater.GetContainer(ctx)); err != nil {
+				deleteProject(projectPath)
+				color.PrintFatal(err)
+			}
+		}
+		if err := ensureVolumes(ctx, cController, creater.GetContainer(ctx)); err != nil {
+			deleteProject(projectPath)
+			color.PrintFatal(err)
+		}
+		volumes = append(volumes, volumeNames(creater.GetContainer(ctx))...)
+		cController.Start(ctx, creater).Wait()
+	}
+	registerProject(pController)
+	color.PrintStatus("Project Created", "Run blah project --start to start developing.")
+}
+
+// Returns a callback that saves created containers to persist.db. If the container name is already
+// taken the old container is removed and *creater is started again, onError is called for any other error
+func creationHandler(pController *persistence.PersistedDataController, cController *containers.Controller, creater *containers.ContainerCreator, onError func()) containers.CallbackFn {
+	return func(ctx context.Context, err error) error {
 		if err != nil {
 			if containers.IsErrNeedContainerRemove(err) {
 				id := containers.GetIDFromNeedContainerRemoveError(err)
@@ -157,12 +291,12 @@ func setupProject(ctx context.Context, projectPath string) {
 				})
 
 				cController.Start(ctx, remover).Wait()
-				cController.Start(ctx, creater).Wait()
+				cController.Start(ctx, *creater).Wait()
 
 				return nil
 			}
 
-			deleteProject(projectPath)
+			onError()
 			return err
 		}
 
@@ -170,64 +304,70 @@ func setupProject(ctx context.Context, projectPath string) {
 			//save container to persist.db
 			err := pController.Persist(container)
 			if err != nil {
-				deleteProject(projectPath)
+				onError()
 				return err
 			}
 		}
 
 		return nil
 	}
+}
 
-	//Pull default images
-	images := []*containers.Image{
-		{Name: mongodb.DefaultImgTag},
-		{Name: mysql.DefaultImgTag},
-		{Name: nginx.DefaultImgTag},
+// Pulls images concurrently according to the pull policy and waits for every pull to finish.
+// onError is called with the first error encountered
+func pullImages(ctx context.Context, cController *containers.Controller, policy containers.PullPolicy, images []*containers.Image, onError func(err error)) {
+	var once sync.Once
+	handlePull := func(ctx context.Context, err error) error {
+		if err != nil {
+			once.Do(func() { onError(err) })
+		}
+		return nil
 	}
+	progress := containers.NewPullProgress(os.Stdout)
+	pulls := make([]*sync.WaitGroup, len(images))
 	for i := range images {
-		puller := containers.NewImagePullPayload(images[i], containers.DefaultImagePullWriter, nil)
-		cController.Start(ctx, puller).Wait()
+		puller := containers.NewImagePullPayload(images[i], policy, progress.Writer(images[i].Name), handlePull)
+		pulls[i] = cController.Start(ctx, puller)
+	}
+	for i := range pulls {
+		pulls[i].Wait()
 	}
+	progress.Flush()
+}
 
-	if creater, err = nginx.InitialSetup(projectName, handleCreation); err != nil {
-		deleteProject(projectPath)
+// Prompts user for one or more databases
+func promptDatabases() []service {
+	output := "Select databases (comma separated EG. 1,2): \n"
+	for i := range databaseServices {
+		output += fmt.Sprintf("(%d) %s\n", i+1, databaseServices[i].label)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	input, err := utils.GetRawInput(reader, output+": ")
+	if err != nil {
 		color.PrintFatal(err)
 	}
-	//not calling wait here nginx container is created during the database prompt prompt
-	cController.Start(ctx, creater)
-
-	switch promptDBType() {
-	case MONGO_SEL:
-		if creater, err = mongodb.InitialSetup(projectName, handleCreation); err != nil {
-			deleteProject(projectPath)
-			color.PrintFatal(err)
+	var selected []service
+	chosen := make(map[int]bool)
+	for _, field := range strings.Split(input, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 || n > len(databaseServices) {
+			color.PrintYellow(fmt.Sprintf("Select numbers between 1 and %d separated by commas.", len(databaseServices)))
+			return promptDatabases()
 		}
-		cController.Start(ctx, creater).Wait()
-	case MYSQL_SEL:
-		if creater, err = mysql.InitialSetup(projectName, handleCreation); err != nil {
-			deleteProject(projectPath)
-			color.PrintFatal(err)
+		if !chosen[n] {
+			chosen[n] = true
+			selected = append(selected, databaseServices[n-1])
 		}
-		cController.Start(ctx, creater).Wait()
 	}
-	color.PrintStatus("Project Created", "Run blah project --start to start developing.")
+	return selected
 }
 
-// Prompts user for the database type
-func promptDBType() int {
-	var db string
-	output := "Select a database: \n(1) MongoDB\n(2) Mysql\n: "
+// Prompts user whether an add-on service should be added to the project
+func promptAddon(name string) bool {
 	reader := bufio.NewReader(os.Stdin)
-	err := utils.GetInput(reader, output, &db, false, "Database Type")
+	add, err := utils.GetConfirmation(reader, fmt.Sprintf("Add %s to the project? (y/n): ", name))
 	if err != nil {
 		color.PrintFatal(err)
 	}
-	if db == "1" {
-		return MONGO_SEL
-	}
-	if db == "2" {
-		return MYSQL_SEL
-	}
-	color.PrintYellow("Select 1 for Mongodb or 2 for Mysql.")
-	return promptDBType()
+	return add
 }
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/docker/api/types"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/registry"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectsCmd = &cobra.Command{
+		Use:   "projects",
+		Short: "Manage the registry of the blah projects on this machine",
+	}
+	projectsListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the projects with their services and host ports",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listProjects()
+		},
+	}
+	projectsPruneCmd = &cobra.Command{
+		Use:   "prune",
+		Short: "Remove the projects whose directory was deleted or moved from the registry",
+		Long: "Removes the projects whose directory was deleted or moved from the registry and offers to delete\n" +
+			"the containers and volumes that are still labeled with their name. Nothing is deleted while the\n" +
+			"directory still exists or a container of the project is running since it may only have been moved,\n" +
+			"--yes only deletes the containers and keeps the volumes.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			pruneProjects(context.Background())
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(projectsCmd)
+	projectsCmd.AddCommand(projectsListCmd)
+	projectsCmd.AddCommand(projectsPruneCmd)
+	projectsPruneCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Delete the containers of the pruned projects without asking, their volumes are kept")
+}
+
+func loadRegistry() *registry.Registry {
+	r, err := registry.Load()
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	return r
+}
+
+func listProjects() {
+	r := loadRegistry()
+	if len(r.Projects) == 0 {
+		color.PrintYellow("No projects registered, they are added by blah init")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tSERVICES\tPORTS")
+	for _, p := range r.Projects {
+		path := p.Path
+		if !p.Exists() {
+			path += " (missing)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", p.Name, path, strings.Join(p.Services, ","), strings.Join(p.Ports, ","))
+	}
+	w.Flush()
+}
+
+// Forgets the projects that no longer exist and offers to remove the containers and volumes labeled with their name
+func pruneProjects(ctx context.Context) {
+	var pruned []registry.Project
+	r, err := registry.Update(func(r *registry.Registry) error {
+		pruned = r.Prune()
+		return nil
+	})
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	if len(pruned) == 0 {
+		color.PrintStatus("Pruned", "Every registered project still exists")
+		return
+	}
+	cController := connectEngine(ctx)
+	for _, p := range pruned {
+		color.PrintStatus("Pruned", fmt.Sprintf("%s %s", p.Name, p.Path))
+		//the labels only hold the name so the resources of a project that still exists under the same name are kept
+		if len(r.Find(p.Name)) > 0 {
+			color.PrintYellow(fmt.Sprintf("Another project is named %s, its containers and volumes are kept", p.Name))
+			continue
+		}
+		//only persist.db was moved or deleted, the data of the project may still be used
+		if utils.FileExists(p.Path) {
+			color.PrintYellow(fmt.Sprintf("%s still exists, the containers and volumes of %s are kept", p.Path, p.Name))
+			continue
+		}
+		removeProjectResources(ctx, cController, p.Name)
+	}
+}
+
+// Removes the containers and volumes labeled with the project name after the user confirmed it.
+// A project with running containers was most likely moved so nothing is removed,
+// when the user is not asked the volumes are kept since their data can not be recovered
+func removeProjectResources(ctx context.Context, cController *containers.Controller, projectName string) {
+	labels := map[string]string{containers.LabelProject: projectName}
+	list, err := listLabeledContainers(ctx, cController, labels)
+	if err != nil {
+		color.PrintError("Container", err)
+		return
+	}
+	volumes, err := listLabeledVolumes(ctx, cController, labels)
+	if err != nil {
+		color.PrintError("Volume", err)
+		return
+	}
+	if len(list)+len(volumes) == 0 {
+		return
+	}
+	var names, containerNames, volumeNames []string
+	for i := range list {
+		if list[i].State == "running" {
+			color.PrintYellow(fmt.Sprintf("%s is running, if the project was moved run blah start in its new directory to register it again", containerName(list[i])))
+			return
+		}
+		containerNames = append(containerNames, containerName(list[i]))
+		names = append(names, containerName(list[i]))
+	}
+	for i := range volumes {
+		volumeNames = append(volumeNames, volumes[i].Name)
+		names = append(names, volumes[i].Name)
+	}
+	deleteVolumes := false
+	if !assumeYes {
+		color.PrintYellow(fmt.Sprintf("%s still has the containers and volumes %s", projectName, strings.Join(names, ", ")))
+		remove, err := utils.GetConfirmation(bufio.NewReader(os.Stdin), "Delete them and their data? (y/n): ")
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		if !remove {
+			return
+		}
+		deleteVolumes = true
+	}
+	conSlice := make([]*containers.Container, len(list))
+	for i := range list {
+		conSlice[i] = &containers.Container{ContainerID: list[i].ID}
+	}
+	removeContainers(ctx, cController, conSlice, deleteVolumes)
+	if len(containerNames) > 0 {
+		color.PrintStatus("Removed", strings.Join(containerNames, ", "))
+	}
+	if len(volumeNames) == 0 {
+		return
+	}
+	if !deleteVolumes {
+		color.PrintYellow(fmt.Sprintf("Kept the volumes %s, delete them with docker volume rm once their data is no longer needed", strings.Join(volumeNames, " ")))
+		return
+	}
+	//the volumes can only be removed once the containers using them are gone
+	if err := removeVolumes(ctx, cController, volumeNames); err != nil {
+		color.PrintError("Volume", err)
+		return
+	}
+	color.PrintStatus("Removed", strings.Join(volumeNames, ", "))
+}
+
+// Lists the volumes that have the labels
+func listLabeledVolumes(ctx context.Context, cController *containers.Controller, labels map[string]string) ([]*types.Volume, error) {
+	var (
+		list    []*types.Volume
+		listErr error
+	)
+	lister := containers.NewVolumeListPayload(labels, func(ctx context.Context, err error) error {
+		if err != nil {
+			listErr = err
+			return nil
+		}
+		list, _ = containers.FromVolumeListContext(ctx)
+		return nil
+	})
+	cController.Start(ctx, lister).Wait()
+	return list, listErr
+}
+
+// Records the project in the working directory with the services and host ports of its persisted containers.
+// The registry is a convenience so failing to update it does not fail the command
+func registerProject(pController *persistence.PersistedDataController) {
+	conSlice, err := pController.GetAllContainers()
+	if err != nil {
+		color.PrintError("Registry", err)
+		return
+	}
+	p := registry.Project{Name: currentProjectName(), Path: utils.GetAbsChild(".")}
+	services := make(map[string]bool)
+	ports := make(map[string]bool)
+	for _, c := range conSlice {
+		if c.Service != "" && !services[c.Service] {
+			services[c.Service] = true
+			p.Services = append(p.Services, c.Service)
+		}
+		for _, binding := range c.PortBindings {
+			if !ports[binding.HostPort] {
+				ports[binding.HostPort] = true
+				p.Ports = append(p.Ports, binding.HostPort)
+			}
+		}
+	}
+	sort.Strings(p.Services)
+	sort.Strings(p.Ports)
+
+	if _, err := registry.Update(func(r *registry.Registry) error {
+		r.Register(p)
+		return nil
+	}); err != nil {
+		color.PrintError("Registry", err)
+	}
+}
+
+// Returns the host ports of the other registered projects and of the given containers, new services
+// of the project at path get ports that do not collide with them
+func usedPorts(path string, conSlice []*containers.Container) map[string]bool {
+	used := make(map[string]bool)
+	if r, err := registry.Load(); err == nil {
+		used = r.UsedPorts(path)
+	} else {
+		color.PrintError("Registry", err)
+	}
+	for _, c := range conSlice {
+		for _, binding := range c.PortBindings {
+			used[binding.HostPort] = true
+		}
+	}
+	return used
+}
+
+// Changes into the directory of a registered project so commands can be run from anywhere EG. blah start myproj
+func chdirProject(name string) {
+	found := loadRegistry().Find(name)
+	switch len(found) {
+	case 0:
+		color.PrintFatal(fmt.Errorf("Project %s is not registered, see blah projects list", name))
+	case 1:
+	default:
+		paths := make([]string, len(found))
+		for i := range found {
+			paths[i] = found[i].Path
+		}
+		color.PrintFatal(fmt.Errorf("Several projects are named %s, cd into one of %s", name, strings.Join(paths, ", ")))
+	}
+	if !found[0].Exists() {
+		color.PrintFatal(fmt.Errorf("Project %s no longer exists at %s, remove it with blah projects prune", name, found[0].Path))
+	}
+	utils.Chdir(found[0].Path)
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/nginx"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	proxyCmd = &cobra.Command{
+		Use:   "proxy",
+		Short: "Manage the reverse proxy routes of the nginx container",
+	}
+	proxyAddCmd = &cobra.Command{
+		Use:   "add <path-or-host> <upstream>",
+		Short: "Proxy a path or host to an upstream, nginx is reloaded if the configuration is valid",
+		Example: "blah proxy add /api host.docker.internal:3000\n" +
+			"blah proxy add api.myproj.localhost http://host.docker.internal:3000",
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			route, err := nginx.NewProxyRoute(args[0], args[1])
+			if err != nil {
+				color.PrintFatal(err)
+			}
+			updateProxyRoutes(context.Background(), func(routes []containers.ProxyRoute) []containers.ProxyRoute {
+				for i := range routes {
+					if routes[i].Pattern == route.Pattern {
+						routes[i].Upstream = route.Upstream
+						return routes
+					}
+				}
+				return append(routes, *route)
+			})
+			color.PrintStatus("Proxy", fmt.Sprintf("%s -> %s", route.Pattern, route.Upstream))
+		},
+	}
+	proxyRemoveCmd = &cobra.Command{
+		Use:     "remove <path-or-host>",
+		Short:   "Remove a proxy route, nginx is reloaded if the configuration is valid",
+		Example: "blah proxy remove /api",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			updateProxyRoutes(context.Background(), func(routes []containers.ProxyRoute) []containers.ProxyRoute {
+				for i := range routes {
+					if routes[i].Pattern == args[0] {
+						return append(routes[:i], routes[i+1:]...)
+					}
+				}
+				color.PrintFatal(fmt.Errorf("There is no proxy route for %s", args[0]))
+				return routes
+			})
+			color.PrintStatus("Proxy", fmt.Sprintf("Removed %s", args[0]))
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.AddCommand(proxyAddCmd)
+	proxyCmd.AddCommand(proxyRemoveCmd)
+}
+
+// Renders the updated routes into nginx.conf, validates and reloads the running nginx container.
+// The previous nginx.conf is restored if the configuration test fails
+func updateProxyRoutes(ctx context.Context, update func(routes []containers.ProxyRoute) []containers.ProxyRoute) {
+	pController, cController := loadProject(ctx)
+	c, confPath := findNginx(pController)
+
+	routes, err := pController.GetAllProxyRoutes()
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	updated := update(append([]containers.ProxyRoute(nil), routes...))
+	previous, err := nginx.WriteProxyConfig(confPath, nginx.ProxyConfig{
+		Routes:     updated,
+		TLS:        nginx.TLSEnabled(c),
+		ServerName: nginx.ServerName(currentProjectName()),
+	})
+	if err != nil {
+		color.PrintFatal(err)
+	}
+
+	if err := reloadNginx(ctx, cController, c.ContainerID); err != nil {
+		if containers.IsErrContainerNotRunning(err) {
+			color.PrintYellow("Nginx is not running, the configuration is tested when the project is started.")
+		} else {
+			if err := ioutil.WriteFile(confPath, previous, 0666); err != nil {
+				color.PrintFatal(err)
+			}
+			color.PrintFatal(fmt.Errorf("nginx.conf was restored because the new configuration is invalid\n%s", nginxTestOutput(err)))
+		}
+	}
+	if err := saveProxyRoutes(pController, routes, updated); err != nil {
+		color.PrintFatal(err)
+	}
+}
+
+// Saves the updated routes and deletes the routes that are no longer part of them
+func saveProxyRoutes(pController *persistence.PersistedDataController, routes []containers.ProxyRoute, updated []containers.ProxyRoute) error {
+	kept := make(map[string]bool)
+	for i := range updated {
+		kept[updated[i].Pattern] = true
+		if err := pController.Persist(&updated[i]); err != nil {
+			return err
+		}
+	}
+	for i := range routes {
+		if !kept[routes[i].Pattern] {
+			if err := pController.DeleteProxyRoute(routes[i].Pattern); err != nil && err != gorm.ErrRecordNotFound {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the nginx container of the project and the host path of its bind mounted nginx.conf
+func findNginx(pController *persistence.PersistedDataController) (*containers.Container, string) {
+	c, confPath, err := lookupNginx(pController, profile.Default)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			color.PrintFatal(fmt.Errorf("Project has no nginx service, add it with blah add nginx"))
+		}
+		color.PrintFatal(err)
+	}
+	return c, confPath
+}
+
+// Same as findNginx for the nginx container of a profile but returns gorm.ErrRecordNotFound instead of exiting
+// if the project has no nginx service
+func lookupNginx(pController *persistence.PersistedDataController, profileName string) (*containers.Container, string, error) {
+	name := profile.ContainerName(currentProjectName(), profileName, nginx.ServiceName)
+	c, err := pController.FindContainerByService(profile.Stored(profileName), nginx.ServiceName, name)
+	if err != nil {
+		return nil, "", err
+	}
+	confPath, ok := c.HostPath(nginx.ConfigTarget)
+	if !ok {
+		return nil, "", fmt.Errorf("%s does not mount %s", c.Name, nginx.ConfigTarget)
+	}
+	return c, confPath, nil
+}
+
+// Tests the configuration with nginx -t inside the container and reloads nginx only if the test passes
+func reloadNginx(ctx context.Context, cController *containers.Controller, ID string) error {
+	for _, cmd := range [][]string{{"nginx", "-t"}, {"nginx", "-s", "reload"}} {
+		var execErr error
+		execer := containers.NewContainerExecPayload(ID, cmd, func(ctx context.Context, err error) error {
+			execErr = err
+			return nil
+		})
+		cController.Start(ctx, execer).Wait()
+		if execErr != nil {
+			return execErr
+		}
+	}
+	return nil
+}
+
+// Tests the configuration the host files of the nginx container hold now with nginx -t in a throwaway helper
+// container. The running container keeps the file it was started with when an editor replaces the file
+func testNginxConf(ctx context.Context, cController *containers.Controller, c *containers.Container) error {
+	helper := containers.Container{
+		Name:        c.Name + "_config_test",
+		Project:     c.Project,
+		Image:       c.Image,
+		ImageDigest: c.ImageDigest,
+		//upstreams have to resolve for the test to pass so the helper joins the network of the project
+		Network:    c.Network,
+		Entrypoint: []string{"nginx", "-t"},
+	}
+	for _, m := range c.Mounts {
+		helper.Mounts = append(helper.Mounts, containers.ContainerMount{Type: m.Type, Source: m.Source, Tagret: m.Tagret})
+	}
+	for _, extraHost := range c.ExtraHosts {
+		helper.ExtraHosts = append(helper.ExtraHosts, containers.ContainerExtraHost{Host: extraHost.Host})
+	}
+	return runHelper(ctx, cController, helper)
+}
+
+// Trims the output of a failed nginx -t to the lines that explain the failure
+func nginxTestOutput(err error) string {
+	var lines []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if strings.Contains(line, "[emerg]") || strings.Contains(line, "failed") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	if len(lines) == 0 {
+		return err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
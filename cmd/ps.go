@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/docker/api/types"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/spf13/cobra"
+)
+
+var (
+	allProjects bool
+	psCmd       = &cobra.Command{
+		Use:     "ps",
+		Short:   "List the containers of the project found by their labels, persist.db is not needed",
+		Example: "blah ps\nblah ps --all-projects",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			listProjectContainers(context.Background())
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+	psCmd.Flags().BoolVar(&allProjects, "all-projects", false, "List the containers of every blah project")
+}
+
+// Prints the containers labeled with the project of the working directory or with any project
+func listProjectContainers(ctx context.Context) {
+	cController := connectEngine(ctx)
+	labels := map[string]string{containers.LabelProject: ""}
+	if !allProjects {
+		labels[containers.LabelProject] = currentProjectName()
+	}
+	list, err := listLabeledContainers(ctx, cController, labels)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	if len(list) == 0 {
+		color.PrintYellow("No containers found")
+		return
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].Labels, list[j].Labels
+		if a[containers.LabelProject] != b[containers.LabelProject] {
+			return a[containers.LabelProject] < b[containers.LabelProject]
+		}
+		return containerName(list[i]) < containerName(list[j])
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tPROFILE\tSERVICE\tNAME\tSTATUS")
+	for _, c := range list {
+		profileName := c.Labels[containers.LabelProfile]
+		if profileName == "" {
+			profileName = profile.Default
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			c.Labels[containers.LabelProject],
+			profileName,
+			c.Labels[containers.LabelService],
+			containerName(c),
+			c.Status,
+		)
+	}
+	w.Flush()
+}
+
+// Lists the running and stopped containers that have the labels and waits for the result
+func listLabeledContainers(ctx context.Context, cController *containers.Controller, labels map[string]string) ([]types.Container, error) {
+	var (
+		list    []types.Container
+		listErr error
+	)
+	lister := containers.NewContainerListPayload(labels, func(ctx context.Context, err error) error {
+		if err != nil {
+			listErr = err
+			return nil
+		}
+		list, _ = containers.FromContainerListContext(ctx)
+		return nil
+	})
+	cController.Start(ctx, lister).Wait()
+	return list, listErr
+}
+
+// Returns the name of a listed container without the leading slash docker reports EG. myproj_mongodb
+func containerName(c types.Container) string {
+	if len(c.Names) == 0 {
+		return c.ID[:12]
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/isolateminds/blah/internal/app"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/envfile"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover",
+	Short: "Rebuild a lost persist.db of the project in the working directory from the labels of its containers",
+	Long: "The containers labeled with the project are inspected and saved to a new persist.db, " +
+		"their env is added to .env files where it is missing. Proxy routes are not recovered, they are kept in nginx.conf.",
+	Example: "cd myproj && blah recover",
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		recoverProject(context.Background())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recoverCmd)
+}
+
+// Inspects the containers of the project and persists them with their profiles to a new persist.db
+func recoverProject(ctx context.Context) {
+	if utils.FileExists("persist.db") {
+		color.PrintFatal(fmt.Errorf("persist.db already exists, move it away to rebuild it"))
+	}
+	cController := connectEngine(ctx)
+	projectName := currentProjectName()
+
+	list, err := listLabeledContainers(ctx, cController, map[string]string{containers.LabelProject: projectName})
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	var recovered []*containers.Container
+	for _, listed := range list {
+		//helper containers have no service and ephemeral copies are removed by docker on stop
+		if listed.Labels[containers.LabelService] == "" {
+			continue
+		}
+		c, err := inspectLabeledContainer(ctx, cController, listed.ID)
+		if err != nil {
+			color.PrintFatal(err)
+		}
+		if !c.AutoRemove {
+			recovered = append(recovered, c)
+		}
+	}
+	if len(recovered) == 0 {
+		color.PrintFatal(fmt.Errorf("No containers labeled with project %s found, see blah ps --all-projects", projectName))
+	}
+
+	pController, err := persistence.NewPersistedDataController("persist.db")
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	for _, c := range recovered {
+		if err := pController.Persist(c); err != nil {
+			color.PrintFatal(err)
+		}
+		//the env of the app is a copy of the .env, its keys belong to the other services
+		if c.Service != app.ServiceName {
+			if err := mergeMissingEnv(profile.EnvFile(c.Profile), c.Env); err != nil {
+				color.PrintFatal(err)
+			}
+		}
+		color.PrintStatus("Recovered", c.Name)
+	}
+	for _, p := range recoverProfiles(recovered) {
+		if err := pController.Persist(&p); err != nil {
+			color.PrintFatal(err)
+		}
+		color.PrintStatus("Recovered", fmt.Sprintf("profile %s", p.Name))
+	}
+	utils.AppendMissingLines(".gitignore", "database/", ".env")
+	registerProject(pController)
+	color.PrintStatus("Project Recovered", fmt.Sprintf("%d containers of %s are tracked in persist.db again", len(recovered), projectName))
+}
+
+// Inspects a container and its image and rebuilds its configuration
+func inspectLabeledContainer(ctx context.Context, cController *containers.Controller, ID string) (*containers.Container, error) {
+	var (
+		c          containers.Container
+		inspectErr error
+	)
+	inspector := containers.NewContainerInspectPayload(ID, func(ctx context.Context, err error) error {
+		if err != nil {
+			inspectErr = err
+			return nil
+		}
+		inspect, _ := containers.FromContainerInspectContext(ctx)
+		//without the image every setting is kept, including the defaults of the image
+		image, _ := inspectImage(ctx, cController, inspect.Image)
+		c = containers.FromInspect(inspect, image)
+		return nil
+	})
+	cController.Start(ctx, inspector).Wait()
+	return &c, inspectErr
+}
+
+// Adds the variables that are missing from an env file, values that were changed by hand are kept
+func mergeMissingEnv(path string, env []containers.ContainerEnv) error {
+	f, err := envfile.Read(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range env {
+		if _, ok := f.Get(e.Key); !ok {
+			f.Set(e.Key, e.Value)
+		}
+	}
+	return f.Write(path)
+}
+
+// Returns the profiles of the recovered containers. The port offset of a profile is the difference
+// between the host ports of its containers and those of the default profile
+func recoverProfiles(recovered []*containers.Container) []profile.Profile {
+	defaults := make(map[string]*containers.Container)
+	for _, c := range recovered {
+		if c.Profile == "" {
+			defaults[c.Service] = c
+		}
+	}
+	var profiles []profile.Profile
+	seen := make(map[string]bool)
+	for _, c := range recovered {
+		if c.Profile == "" || seen[c.Profile] {
+			continue
+		}
+		seen[c.Profile] = true
+		p := profile.Profile{Name: c.Profile, PortOffset: -1}
+		if d, ok := defaults[c.Service]; ok && len(d.PortBindings) > 0 && len(c.PortBindings) > 0 {
+			from, errFrom := strconv.Atoi(d.PortBindings[0].HostPort)
+			to, errTo := strconv.Atoi(c.PortBindings[0].HostPort)
+			if errFrom == nil && errTo == nil {
+				p.PortOffset = to - from
+			}
+		}
+		if p.PortOffset < 0 {
+			p.PortOffset = profile.NextPortOffset(profiles)
+		}
+		profiles = append(profiles, p)
+	}
+	return profiles
+}
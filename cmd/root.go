@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 
+	"github.com/isolateminds/blah/internal/containers"
 	"github.com/ttacon/chalk"
 
 	"github.com/spf13/cobra"
@@ -29,6 +30,7 @@ func printBanner() {
 var (
 	rootCmd = &cobra.Command{
 		Use:                "blah",
+		Version:            containers.Version,
 		CompletionOptions:  cobra.CompletionOptions{DisableDefaultCmd: true},
 		DisableSuggestions: true,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/isolateminds/blah/internal/app"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/mongodb"
+	"github.com/isolateminds/blah/internal/mysql"
+	"github.com/isolateminds/blah/internal/redis"
+	"github.com/isolateminds/blah/internal/scaffold"
+)
+
+// Generates the config and db packages for the services of the project into src
+func scaffoldSource(lang string, projectName string, services []service) {
+	opt := scaffold.Options{Module: strings.ToLower(projectName)}
+	for i := range services {
+		switch services[i].name {
+		case mongodb.ServiceName:
+			opt.MongoDB = true
+		case mysql.ServiceName:
+			opt.MySQL = true
+		case redis.ServiceName:
+			opt.Redis = true
+		}
+	}
+	written, err := scaffold.Generate(lang, app.SourceDir, opt)
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	for i := range written {
+		color.PrintStatus("Scaffold", written[i])
+	}
+	if len(written) > 0 {
+		color.PrintStatus("Scaffold", fmt.Sprintf("The Go app container runs go mod tidy before it starts, run it in %s to work on the host", app.SourceDir))
+	}
+}
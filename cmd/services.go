@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"github.com/isolateminds/blah/internal/app"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/mongodb"
+	"github.com/isolateminds/blah/internal/mysql"
+	"github.com/isolateminds/blah/internal/nginx"
+	"github.com/isolateminds/blah/internal/redis"
+)
+
+// Prompts for the service configuration and returns a creator for its container
+type setupFn func(projectName string, opt containers.ServiceOptions, cb containers.CallbackFn) (containers.ContainerCreator, error)
+
+// A service that can be part of a project
+type service struct {
+	name       string
+	label      string
+	defaultImg string
+	setup      setupFn
+}
+
+var (
+	nginxService = service{nginx.ServiceName, "Nginx", nginx.DefaultImgTag, nginx.InitialSetup}
+	redisService = service{redis.ServiceName, "Redis", redis.DefaultImgTag, redis.InitialSetup}
+	// The app has no default image, it is built from src/Dockerfile or chosen during its setup
+	appService = service{app.ServiceName, "App", app.DefaultImgTag, app.InitialSetup}
+
+	// Databases in the order they are listed when prompting
+	databaseServices = []service{
+		{mongodb.ServiceName, "MongoDB", mongodb.DefaultImgTag, mongodb.InitialSetup},
+		{mysql.ServiceName, "Mysql", mysql.DefaultImgTag, mysql.InitialSetup},
+	}
+)
+
+// Returns every service blah knows about
+func allServices() []service {
+	services := []service{nginxService}
+	services = append(services, databaseServices...)
+	return append(services, redisService, appService)
+}
+
+// Returns the names of the services
+func serviceNames(services []service) []string {
+	names := make([]string, len(services))
+	for i := range services {
+		names[i] = services[i].name
+	}
+	return names
+}
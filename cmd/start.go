@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/nginx"
+	"github.com/isolateminds/blah/internal/persistence"
+	"github.com/isolateminds/blah/internal/profile"
+	"github.com/isolateminds/blah/internal/utils"
+	"github.com/isolateminds/blah/internal/watcher"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+const watchDebounce = 300 * time.Millisecond
+
+var (
+	background bool
+	startCmd   = &cobra.Command{
+		Use:     "start [project]",
+		Short:   "Start the project containers until Ctrl+C, same as blah project --start",
+		Long:    "Starts the project in the working directory or a project of blah projects list from any directory.",
+		Example: "blah start --profile test\nblah start --background\nblah start myproj",
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 {
+				chdirProject(args[0])
+			}
+			loadProfileEnv(profileName)
+			startProject(context.Background(), false, profileName)
+		},
+	}
+	stopCmd = &cobra.Command{
+		Use:     "stop [project]",
+		Short:   "Stop the project containers started with blah start --background",
+		Example: "blah stop --profile test\nblah stop myproj",
+		Args:    cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 1 {
+				chdirProject(args[0])
+			}
+			stopProject(context.Background(), profileName)
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	addRunFlags(startCmd.Flags())
+	startCmd.Flags().BoolVar(&background, "background", false, "Start the containers and return, they keep running until blah stop")
+	stopCmd.Flags().StringVar(&profileName, "profile", profile.Default, "Profile to stop EG. test")
+}
+
+// Starts every container of a profile and reacts to file system and container events until Ctrl+C.
+// In dev mode the app container is rebuilt or restarted when its source changes and its logs are streamed.
+// In the background the containers are only started and keep running after blah exits
+func startProject(ctx context.Context, dev bool, profileName string) {
+	pController, cController := loadProject(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ensureProfile(ctx, pController, cController, profileName)
+	//projects created before the registry existed are recorded the first time they are started
+	registerProject(pController)
+	conSlice, err := pController.GetProfileContainers(profile.Stored(profileName))
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	if ephemeral {
+		conSlice = ephemeralContainers(ctx, cController, conSlice)
+	}
+	if background {
+		startContainers(ctx, cController, conSlice)
+		color.PrintStatus("Background", "Containers keep running, stop them with blah stop")
+		return
+	}
+	var app *appWatch
+	if dev {
+		app = watchApp(ctx, pController, cController, conSlice, profileName)
+	}
+
+	started := time.Now()
+	startContainers(ctx, cController, conSlice)
+	ng := watchNginx(ctx, pController, cController, profileName)
+	app.streamLogs(ctx, cController, started)
+	color.PrintForInput("Type Ctrl+C to stop running containers\n")
+
+	runEventLoop(ctx, pController, cController, conSlice, ng, app)
+
+	cancel()
+	stopContainers(context.Background(), cController, conSlice)
+}
+
+// Stops the containers of a profile that run in the background, ephemeral copies are removed by docker
+func stopProject(ctx context.Context, profileName string) {
+	pController, cController := loadProject(ctx)
+	if err := profile.Validate(profileName); err != nil {
+		color.PrintFatal(err)
+	}
+	conSlice, err := pController.GetProfileContainers(profile.Stored(profileName))
+	if err != nil {
+		color.PrintFatal(err)
+	}
+	for _, c := range conSlice {
+		if len(serviceDataMounts(c)) == 0 {
+			continue
+		}
+		//the ephemeral copy runs instead of the persisted container if the project was started with --ephemeral
+		name := ephemeralCopy(c).Name
+		stopper := containers.NewContainerStopperPayload(&name, func(ctx context.Context, err error) error {
+			if err != nil && !errdefs.IsNotFound(err) {
+				return err
+			}
+			return nil
+		})
+		cController.Start(ctx, stopper).Wait()
+	}
+	stopContainers(ctx, cController, conSlice)
+}
+
+func startContainers(ctx context.Context, cController *containers.Controller, conSlice []*containers.Container) {
+	color.PrintStatus("Container", "Starting....")
+	for i := range conSlice {
+		starter := containers.NewStartContainerPayload(conSlice[i].ContainerID, nil)
+		cController.Start(ctx, starter).Wait()
+		color.PrintStatus("Container", fmt.Sprintf("Started %s", conSlice[i].Name))
+	}
+}
+
+func stopContainers(ctx context.Context, cController *containers.Controller, conSlice []*containers.Container) {
+	for i := range conSlice {
+		stopper := containers.NewContainerStopperPayload(&conSlice[i].ContainerID, nil)
+		cController.Start(ctx, stopper).Wait()
+		color.PrintStatus("Container", fmt.Sprintf("Stopped %s", conSlice[i].Name))
+	}
+}
+
+// Force removes containers, containers that were already removed outside of blah are skipped.
+// removeVolumes also removes the anonymous volumes of the containers
+func removeContainers(ctx context.Context, cController *containers.Controller, conSlice []*containers.Container, removeVolumes bool) {
+	for i := range conSlice {
+		opt := containers.CRMOptions{Force: true, RemoveVolumes: removeVolumes}
+		remover := containers.NewRemoveContainerPayload(conSlice[i].ContainerID, opt, func(ctx context.Context, err error) error {
+			if err != nil && !errdefs.IsNotFound(err) {
+				color.PrintError("Container", err)
+			}
+			return nil
+		})
+		cController.Start(ctx, remover).Wait()
+	}
+}
+
+// Reacts to changes of the watched files and to containers that exit until Ctrl+C
+func runEventLoop(ctx context.Context, pController *persistence.PersistedDataController, cController *containers.Controller, conSlice []*containers.Container, ng *nginxWatch, app *appWatch) {
+	signals := utils.NotifySIGTERM()
+
+	names := make([]string, len(conSlice))
+	for i := range conSlice {
+		names[i] = conSlice[i].Name
+	}
+	events := make(chan containers.ContainerEvent)
+	eventWatcher := containers.NewContainerEventsPayload(names, events, func(ctx context.Context, err error) error {
+		if err != nil {
+			color.PrintError("Container", err)
+		}
+		return nil
+	})
+	cController.Start(ctx, eventWatcher)
+
+	//containers that blah restarts itself exit as well, those exits are not reported
+	restarted := make(map[string]time.Time)
+	for {
+		select {
+		case <-signals:
+			fmt.Println()
+			return
+		case _, ok := <-ng.events():
+			if !ok {
+				ng = nil
+				continue
+			}
+			if ng.reload(ctx, cController) {
+				restarted[ng.c.Name] = time.Now()
+			}
+		case err := <-ng.errors():
+			color.PrintError("Nginx", err)
+		case changed, ok := <-app.events():
+			if !ok {
+				app = nil
+				continue
+			}
+			if app.reload(ctx, pController, cController, changed) {
+				restarted[app.c.Name] = time.Now()
+			}
+		case err := <-app.errors():
+			color.PrintError("App", err)
+		case event := <-events:
+			if event.Action != "die" || event.Time.Before(restarted[event.Name]) {
+				continue
+			}
+			color.PrintYellow(fmt.Sprintf("%s exited with code %s, see docker logs %s", event.Name, event.ExitCode, event.Name))
+			if app != nil && event.Name == app.c.Name {
+				color.PrintYellow(fmt.Sprintf("The app is restarted on the next change in %s", app.dir))
+			}
+		}
+	}
+}
+
+// Watches nginx.conf and the included files that are bind mounted into the nginx container
+type nginxWatch struct {
+	c        *containers.Container
+	confPath string
+	mounted  os.FileInfo
+	w        *watcher.Watcher
+}
+
+// Starts watching the nginx configuration, nil is returned if the project has no nginx service
+// or the configuration can not be watched
+func watchNginx(ctx context.Context, pController *persistence.PersistedDataController, cController *containers.Controller, profileName string) *nginxWatch {
+	c, confPath, err := lookupNginx(pController, profileName)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			color.PrintError("Nginx", err)
+		}
+		return nil
+	}
+	mounted, err := os.Stat(confPath)
+	if err != nil {
+		color.PrintError("Nginx", err)
+		return nil
+	}
+	w, err := watcher.New(watchDebounce)
+	if err != nil {
+		color.PrintError("Nginx", err)
+		return nil
+	}
+	watched := []string{confPath}
+	if conf, err := ioutil.ReadFile(confPath); err == nil {
+		for _, include := range nginx.IncludedFiles(conf) {
+			if hostPath, ok := c.HostPath(include); ok {
+				watched = append(watched, hostPath)
+			}
+		}
+	}
+	for i := range watched {
+		if err := w.Add(watched[i]); err != nil {
+			color.PrintError("Nginx", err)
+		}
+	}
+	color.PrintStatus("Nginx", fmt.Sprintf("Watching %s for changes", filepath.Base(confPath)))
+	return &nginxWatch{c: c, confPath: confPath, mounted: mounted, w: w}
+}
+
+// Receives a batch for every change, a nil watch never receives
+func (n *nginxWatch) events() <-chan []string {
+	if n == nil {
+		return nil
+	}
+	return n.w.Events()
+}
+func (n *nginxWatch) errors() <-chan error {
+	if n == nil {
+		return nil
+	}
+	return n.w.Errors()
+}
+
+// Tests the changed configuration and reloads nginx only if the test passes.
+// Returns true if the container had to be restarted
+func (n *nginxWatch) reload(ctx context.Context, cController *containers.Controller) bool {
+	restarted := false
+	current, err := os.Stat(n.confPath)
+	if err != nil {
+		color.PrintError("Nginx", err)
+		return false
+	}
+	//editors that save by replacing the file break the bind mount, the container keeps
+	//seeing the old file until it is restarted. The new file is tested first so a broken
+	//configuration does not keep nginx from starting again
+	if !os.SameFile(n.mounted, current) {
+		if err := testNginxConf(ctx, cController, n.c); err != nil {
+			color.PrintError("Nginx", fmt.Sprintf("nginx.conf was replaced by a new file that does not pass nginx -t, nginx keeps running with the previous configuration\n%s", nginxTestOutput(err)))
+			return false
+		}
+		n.mounted = current
+		color.PrintYellow("nginx.conf was replaced by a new file, restarting nginx so it picks up the change")
+		restartContainer(ctx, cController, n.c.ContainerID)
+		restarted = true
+	}
+	if err := reloadNginx(ctx, cController, n.c.ContainerID); err != nil {
+		if containers.IsErrContainerNotRunning(err) {
+			color.PrintError("Nginx", "nginx is not running, check the configuration with docker logs "+n.c.Name)
+			return restarted
+		}
+		color.PrintError("Nginx", fmt.Sprintf("Configuration not reloaded\n%s", nginxTestOutput(err)))
+		return restarted
+	}
+	color.PrintStatus("Nginx", "Configuration reloaded")
+	return restarted
+}
+
+// Stops and starts a container again
+func restartContainer(ctx context.Context, cController *containers.Controller, ID string) {
+	stopper := containers.NewContainerStopperPayload(&ID, nil)
+	cController.Start(ctx, stopper).Wait()
+	starter := containers.NewStartContainerPayload(ID, func(ctx context.Context, err error) error {
+		if err != nil {
+			color.PrintError("Container", err)
+		}
+		return nil
+	})
+	cController.Start(ctx, starter).Wait()
+}
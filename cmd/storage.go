@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/utils"
+)
+
+var storage string
+
+// Returns the storage the databases of a project use, volume if any container mounts a data volume
+func projectStorage(conSlice []*containers.Container) containers.Storage {
+	for _, c := range conSlice {
+		for _, m := range serviceDataMounts(c) {
+			if m.Type == mount.TypeVolume {
+				return containers.StorageVolume
+			}
+		}
+	}
+	return containers.StorageBind
+}
+
+// Returns the mounts that keep the data of a container, directories in the project database directory
+// and named volumes
+func serviceDataMounts(c *containers.Container) []containers.ContainerMount {
+	databasePath := utils.GetAbsChild("database")
+	var mounts []containers.ContainerMount
+	for _, m := range c.Mounts {
+		if m.Type == mount.TypeVolume {
+			mounts = append(mounts, m)
+			continue
+		}
+		rel, err := filepath.Rel(databasePath, m.Source)
+		if err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			mounts = append(mounts, m)
+		}
+	}
+	return mounts
+}
+
+// Creates the named volumes of a new container. A volume with the same name that already exists holds the data
+// of a project that was deleted without blah, the databases skip their setup for existing data so the credentials
+// of the new .env would not work. It is only replaced after the user confirmed it
+func ensureVolumes(ctx context.Context, cController *containers.Controller, c containers.Container) error {
+	var volumeErr error
+	for _, m := range c.Mounts {
+		if m.Type != mount.TypeVolume {
+			continue
+		}
+		if err := replaceStaleVolume(ctx, cController, m.Source); err != nil {
+			return err
+		}
+		creator := containers.NewVolumeCreatePayload(m.Source, c.CreateLabels(), func(ctx context.Context, err error) error {
+			volumeErr = err
+			return nil
+		})
+		if cController.Start(ctx, creator).Wait(); volumeErr != nil {
+			return volumeErr
+		}
+	}
+	return nil
+}
+
+// Asks to remove a volume that exists already, an error is returned if the user keeps it
+func replaceStaleVolume(ctx context.Context, cController *containers.Controller, name string) error {
+	var (
+		existing   *types.Volume
+		inspectErr error
+	)
+	inspector := containers.NewVolumeInspectPayload(name, func(ctx context.Context, err error) error {
+		if err != nil {
+			if !containers.IsErrVolumeNotFound(err) {
+				inspectErr = err
+			}
+			return nil
+		}
+		existing, _ = containers.FromVolumeInspectContext(ctx)
+		return nil
+	})
+	if cController.Start(ctx, inspector).Wait(); inspectErr != nil || existing == nil {
+		return inspectErr
+	}
+	color.PrintYellow(fmt.Sprintf("Volume %s already exists since %s, it holds the data of a previous project and the new credentials of the .env would not work with it.", name, existing.CreatedAt))
+	reader := bufio.NewReader(os.Stdin)
+	remove, err := utils.GetConfirmation(reader, "Delete the volume and its data? (y/n): ")
+	if err != nil {
+		return err
+	}
+	if !remove {
+		return fmt.Errorf("Volume %s already exists, remove it with docker volume rm %s or use --storage=bind", name, name)
+	}
+	if err := removeVolumes(ctx, cController, []string{name}); err != nil {
+		return fmt.Errorf("Could not remove volume %s, remove the containers that use it first: %w", name, err)
+	}
+	return nil
+}
+
+// Asks to empty the data directories of a new container that still hold the data of a removed service EG. after
+// blah remove --keep-data. The databases skip their setup for existing data so the credentials of the new .env
+// would not work, an error is returned if the user keeps the data
+func replaceStaleDataDirs(c containers.Container) error {
+	for _, m := range serviceDataMounts(&c) {
+		if m.Type != mount.TypeBind {
+			continue
+		}
+		entries, err := ioutil.ReadDir(m.Source)
+		if err != nil || len(entries) == 0 {
+			continue
+		}
+		color.PrintYellow(fmt.Sprintf("%s is not empty, it holds the data of a previous %s and the new credentials of the .env would not work with it.", m.Source, c.Service))
+		reader := bufio.NewReader(os.Stdin)
+		remove, err := utils.GetConfirmation(reader, "Delete the data? (y/n): ")
+		if err != nil {
+			return err
+		}
+		if !remove {
+			return fmt.Errorf("%s is not empty, move it away or use --storage=volume", m.Source)
+		}
+		if err := os.RemoveAll(m.Source); err != nil {
+			return fmt.Errorf("Could not delete %s, run blah fix-permissions first: %w", m.Source, err)
+		}
+		if err := os.MkdirAll(m.Source, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the named volumes a container mounts
+func volumeNames(c containers.Container) []string {
+	var names []string
+	for _, m := range c.Mounts {
+		if m.Type == mount.TypeVolume {
+			names = append(names, m.Source)
+		}
+	}
+	return names
+}
+
+// Removes named volumes, volumes that were already removed outside of blah are skipped
+func removeVolumes(ctx context.Context, cController *containers.Controller, names []string) error {
+	var volumeErr error
+	for _, name := range names {
+		remover := containers.NewVolumeRemovePayload(name, func(ctx context.Context, err error) error {
+			if !containers.IsErrVolumeNotFound(err) {
+				volumeErr = err
+			}
+			return nil
+		})
+		if cController.Start(ctx, remover).Wait(); volumeErr != nil {
+			return volumeErr
+		}
+	}
+	return nil
+}
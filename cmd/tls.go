@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/devcert"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tlsCmd = &cobra.Command{
+		Use:   "tls",
+		Short: "Manage the development CA used for local HTTPS",
+	}
+	tlsTrustCmd = &cobra.Command{
+		Use:   "trust",
+		Short: "Print the path of the development CA certificate to import into browsers",
+		Run: func(cmd *cobra.Command, args []string) {
+			//creates the CA if no project was initialized with --tls yet so there is always something to trust
+			if _, _, err := devcert.LoadOrCreateCA(); err != nil {
+				color.PrintFatal(err)
+			}
+			caPath, err := devcert.CACertPath()
+			if err != nil {
+				color.PrintFatal(err)
+			}
+			fmt.Println(caPath)
+			color.PrintCyan("Import it as a trusted certificate authority in your browser or system trust store EG.")
+			color.PrintCyan(fmt.Sprintf("  Linux:   sudo cp %s /usr/local/share/ca-certificates/blah.crt && sudo update-ca-certificates", caPath))
+			color.PrintCyan(fmt.Sprintf("  macOS:   sudo security add-trusted-cert -d -r trustRoot -k /Library/Keychains/System.keychain %s", caPath))
+			color.PrintCyan("  Firefox: Settings > Privacy & Security > Certificates > View Certificates > Authorities > Import")
+		},
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(tlsCmd)
+	tlsCmd.AddCommand(tlsTrustCmd)
+}
@@ -4,6 +4,9 @@ go 1.18
 
 require (
 	github.com/docker/docker v20.10.17+incompatible
+	github.com/fsnotify/fsnotify v1.5.1
+	github.com/moby/patternmatcher v0.6.0
+	github.com/spf13/pflag v1.0.5
 	gorm.io/gorm v1.23.4
 )
 
@@ -14,16 +17,15 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.12 // indirect
 	github.com/moby/term v0.0.0-20210619224110-3f7ff695adc6 // indirect
 	github.com/morikuni/aec v1.0.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/time v0.0.0-20220722155302-e5dcc9cfc0b9 // indirect
 	gotest.tools/v3 v3.3.0 // indirect
 )
 
 require (
 	github.com/Microsoft/go-winio v0.5.2 // indirect
-	github.com/docker/distribution v2.8.1+incompatible // indirect
+	github.com/docker/distribution v2.8.1+incompatible
 	github.com/docker/go-connections v0.4.0
-	github.com/docker/go-units v0.4.0 // indirect
+	github.com/docker/go-units v0.4.0
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/joho/godotenv v1.4.0
 	github.com/opencontainers/go-digest v1.0.0 // indirect
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/envfile"
+	"github.com/isolateminds/blah/internal/utils"
+)
+
+var (
+	defaultHostPort = "8000"
+	containerPort   = "8080"
+
+	// The app image is built from src/Dockerfile or picked from runtimes so there is no default
+	DefaultImgTag = ""
+
+	// Base images the source directory can be mounted into when there is no Dockerfile
+	runtimes = []runtime{
+		//go.sum is not committed by every project (and not written by blah init --scaffold) so the
+		//dependencies are resolved before the first run
+		{"Go", "golang:latest", []string{"sh", "-c", "go mod tidy && go run ."}},
+		{"Node", "node:lts", []string{"npm", "start"}},
+		{"Python", "python:3", []string{"python", "main.py"}},
+	}
+)
+
+// Name of the service used for flags and container names EG. myproj_app
+const (
+	ServiceName = "app"
+	// Directory of the project that holds the application source code
+	SourceDir = "src"
+	// Path the source directory is mounted at when the app runs from a base image
+	SourceTarget = "/app"
+)
+
+type runtime struct {
+	label string
+	image string
+	cmd   []string
+}
+
+// Runs the application in src. If src/Dockerfile exists the container uses the image built from it,
+// otherwise the user picks a base image that runs the mounted source directory.
+// The .env of the project is injected with the addresses rewritten to the project network
+func InitialSetup(projectName string, opt containers.ServiceOptions, cb containers.CallbackFn) (containers.ContainerCreator, error) {
+	color.PrintStatus("App", "Setup your application container")
+	env, err := Env(".env", opt.Peers)
+	if err != nil {
+		return nil, err
+	}
+	hostPort := opt.HostPort(defaultHostPort)
+
+	container := containers.Container{
+		Name:     utils.PrefixProjectName(projectName, ServiceName),
+		Project:  projectName,
+		Service:  ServiceName,
+		Network:  opt.Network,
+		Hostname: fmt.Sprintf("com.%s.app", projectName),
+		Env:      env,
+		ExposedPorts: []containers.ContainerExposedPort{
+			{
+				Port: containerPort,
+			},
+		},
+		PortBindings: []containers.ContainerPortBinding{
+			{
+				Port:     containerPort,
+				HostPort: hostPort,
+				HostIP:   "0.0.0.0",
+			},
+		},
+	}
+
+	if utils.FileExists(path.Join(SourceDir, "Dockerfile")) {
+		color.PrintStatus("App", fmt.Sprintf("Building %s/Dockerfile", SourceDir))
+		container.Image = ImageTag(projectName)
+		container.BuildContext = utils.GetAbsChild(SourceDir)
+	} else {
+		r, err := promptRuntime()
+		if err != nil {
+			return nil, err
+		}
+		container.Image = opt.ImageOrDefault(r.image)
+		container.WorkingDir = SourceTarget
+		container.Mounts = []containers.ContainerMount{
+			{
+				Type:   mount.TypeBind,
+				Source: utils.GetAbsChild(SourceDir),
+				Tagret: SourceTarget,
+			},
+		}
+		for i := range r.cmd {
+			container.Cmd = append(container.Cmd, containers.ContainerCmdArg{Arg: r.cmd[i]})
+		}
+	}
+	color.PrintStatus("App", fmt.Sprintf("http://localhost:%s", hostPort))
+
+	return containers.NewCreateContainerPayload(&container, cb), nil
+}
+
+// Returns the tag of the image built from src/Dockerfile EG. myproj_app:dev
+func ImageTag(projectName string) string {
+	return strings.ToLower(utils.PrefixProjectName(projectName, ServiceName)) + ":dev"
+}
+
+// Returns the command as it is typed in a shell
+func (r runtime) command() string {
+	if len(r.cmd) == 3 && r.cmd[0] == "sh" && r.cmd[1] == "-c" {
+		return r.cmd[2]
+	}
+	return strings.Join(r.cmd, " ")
+}
+
+// Prompts user for the base image the source directory is run with
+func promptRuntime() (runtime, error) {
+	output := fmt.Sprintf("No %s/Dockerfile found, select the runtime of your app: \n", SourceDir)
+	for i := range runtimes {
+		output += fmt.Sprintf("(%d) %s %s\n", i+1, runtimes[i].label, runtimes[i].command())
+	}
+	reader := bufio.NewReader(os.Stdin)
+	input, err := utils.GetRawInput(reader, output+": ")
+	if err != nil {
+		return runtime{}, err
+	}
+	n, err := strconv.Atoi(input)
+	if err != nil || n < 1 || n > len(runtimes) {
+		color.PrintYellow(fmt.Sprintf("Select a number between 1 and %d.", len(runtimes)))
+		return promptRuntime()
+	}
+	return runtimes[n-1], nil
+}
+
+// Returns the env of the app container, the env file of its profile with the addresses rewritten to the
+// project network. It is built again whenever the app is recreated so services added later are reachable
+func Env(envPath string, peers []*containers.Container) ([]containers.ContainerEnv, error) {
+	env, err := networkEnv(envPath, peers)
+	if err != nil {
+		return nil, err
+	}
+	return append(env, containers.ContainerEnv{Key: "PORT", Value: containerPort}), nil
+}
+
+// Reads the env file and rewrites the host addresses of the other services to their address on the
+// project network EG. localhost:3186 becomes mongodb:27017 and MONGODB_PORT=27017.
+// <SERVICE>_HOST is set to the network alias of every service
+func networkEnv(envPath string, peers []*containers.Container) ([]containers.ContainerEnv, error) {
+	f, err := envfile.Read(envPath)
+	if err != nil {
+		return nil, err
+	}
+	dotenv := f.Map()
+	addresses := make(map[string]string)
+	ports := make(map[string]string)
+	for _, peer := range peers {
+		if peer.Service == "" || peer.Service == ServiceName {
+			continue
+		}
+		dotenv[strings.ToUpper(peer.Service)+"_HOST"] = peer.Service
+		for _, binding := range peer.PortBindings {
+			port := strings.Split(binding.Port, "/")[0]
+			for _, host := range []string{"localhost", "127.0.0.1"} {
+				addresses[fmt.Sprintf("%s:%s", host, binding.HostPort)] = fmt.Sprintf("%s:%s", peer.Service, port)
+			}
+			ports[binding.HostPort] = port
+		}
+	}
+
+	env := make([]containers.ContainerEnv, 0, len(dotenv))
+	for key, value := range dotenv {
+		if port, ok := ports[value]; ok && strings.HasSuffix(key, "_PORT") {
+			value = port
+		}
+		env = append(env, containers.ContainerEnv{Key: key, Value: utils.ReplaceAddresses(value, addresses)})
+	}
+	//map iteration order is random, sorting keeps the persisted env stable
+	sort.Slice(env, func(i, j int) bool { return env[i].Key < env[j].Key })
+	return env, nil
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/isolateminds/blah/internal/containers"
+)
+
+func TestEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	dotenv := "MONGODB_URL=mongodb://admin:pw@localhost:3186/myproj\n" +
+		"MONGODB_PORT=3186\n" +
+		"OTHER_URL=http://localhost:31860/x\n" +
+		"REDIS_URL=redis://:pw@127.0.0.1:6380/0\n" +
+		"BUILD=3186\n"
+	if err := ioutil.WriteFile(path, []byte(dotenv), 0600); err != nil {
+		t.Fatal(err)
+	}
+	peers := []*containers.Container{
+		{Service: "mongodb", PortBindings: []containers.ContainerPortBinding{{Port: "27017", HostPort: "3186"}}},
+		{Service: "redis", PortBindings: []containers.ContainerPortBinding{{Port: "6379/tcp", HostPort: "6380"}}},
+		{Service: ServiceName, PortBindings: []containers.ContainerPortBinding{{Port: "8080", HostPort: "8000"}}},
+	}
+	env, err := Env(path, peers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, e := range env {
+		got[e.Key] = e.Value
+	}
+	want := map[string]string{
+		"MONGODB_URL":  "mongodb://admin:pw@mongodb:27017/myproj",
+		"MONGODB_PORT": "27017",
+		"MONGODB_HOST": "mongodb",
+		"OTHER_URL":    "http://localhost:31860/x",
+		"REDIS_URL":    "redis://:pw@redis:6379/0",
+		"REDIS_HOST":   "redis",
+		"BUILD":        "3186",
+		"PORT":         containerPort,
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d keys %v, want %d", len(got), got, len(want))
+	}
+}
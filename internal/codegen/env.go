@@ -0,0 +1,140 @@
+package codegen
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/isolateminds/blah/internal/envfile"
+)
+
+var (
+	//go:embed env.go.tmpl
+	envTemplate string
+
+	// Languages the env loader can be generated for
+	Languages = []string{"go"}
+
+	// Words of env keys that are not title cased EG. MONGODB_URL becomes MongoDBURL
+	initialisms = map[string]string{
+		"URL":     "URL",
+		"ID":      "ID",
+		"DB":      "DB",
+		"IP":      "IP",
+		"TLS":     "TLS",
+		"MONGODB": "MongoDB",
+		"MYSQL":   "MySQL",
+		"INITDB":  "InitDB",
+	}
+)
+
+// An environment variable managed by blah
+type EnvVar struct {
+	Key string
+	// Variables that only exist inside containers EG. PORT of the app are optional
+	Required bool
+}
+
+// A variable of the generated struct
+type envField struct {
+	Key      string
+	Name     string
+	Int      bool
+	Secret   bool
+	URL      bool
+	Required bool
+}
+
+// Returns an error if lang can not be generated
+func Validate(lang string) error {
+	for i := range Languages {
+		if Languages[i] == lang {
+			return nil
+		}
+	}
+	return fmt.Errorf("Can not generate %s should be one of %s", lang, strings.Join(Languages, ", "))
+}
+
+// Generates the source of a typed Env struct with a loader that reports missing variables and a
+// String method that redacts secrets. The output only depends on vars so it can be compared to check staleness
+func Env(lang string, pkg string, vars []EnvVar) ([]byte, error) {
+	if err := Validate(lang); err != nil {
+		return nil, err
+	}
+	fields := make([]envField, 0, len(vars))
+	seen := make(map[string]int)
+	for i := range vars {
+		key := vars[i].Key
+		if j, ok := seen[key]; ok {
+			fields[j].Required = fields[j].Required || vars[i].Required
+			continue
+		}
+		seen[key] = len(fields)
+		fields = append(fields, envField{
+			Key:      key,
+			Name:     FieldName(key),
+			Int:      strings.HasSuffix(key, "_PORT") || key == "PORT",
+			Secret:   envfile.IsSecret(key),
+			URL:      strings.HasSuffix(key, "_URL"),
+			Required: vars[i].Required,
+		})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	//keys that only differ in separators or case EG. APP.NAME and APP_NAME would declare the same field twice
+	names := make(map[string]string, len(fields))
+	for i := range fields {
+		if key, ok := names[fields[i].Name]; ok {
+			return nil, fmt.Errorf("Env keys %s and %s both become the field %s, rename one of them", key, fields[i].Key, fields[i].Name)
+		}
+		names[fields[i].Name] = fields[i].Key
+	}
+
+	tmpl, err := template.New("env").Parse(envTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Package string
+		Fields  []envField
+		HasInt  bool
+		HasURL  bool
+	}{pkg, fields, anyField(fields, func(f envField) bool { return f.Int }), anyField(fields, func(f envField) bool { return f.URL })})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// Converts an env key to an exported Go field name EG. MYSQL_ROOT_PASSWORD becomes MySQLRootPassword
+func FieldName(key string) string {
+	var name strings.Builder
+	//characters that can not be part of an identifier separate words like underscores EG. APP.NAME
+	words := strings.FieldsFunc(strings.ToUpper(key), func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9')
+	})
+	for _, word := range words {
+		if initialism, ok := initialisms[word]; ok {
+			name.WriteString(initialism)
+			continue
+		}
+		name.WriteString(word[:1] + strings.ToLower(word[1:]))
+	}
+	if name.Len() == 0 || (name.String()[0] >= '0' && name.String()[0] <= '9') {
+		return "Env" + name.String()
+	}
+	return name.String()
+}
+
+func anyField(fields []envField, fn func(f envField) bool) bool {
+	for i := range fields {
+		if fn(fields[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFieldName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"MYSQL_ROOT_PASSWORD", "MySQLRootPassword"},
+		{"MONGODB_URL", "MongoDBURL"},
+		{"MONGO_INITDB_ROOT_USERNAME", "MongoInitDBRootUsername"},
+		{"REDIS_HOST", "RedisHost"},
+		{"APP_ID", "AppID"},
+		{"TLS_ENABLED", "TLSEnabled"},
+		{"port", "Port"},
+		{"Db_Name", "DBName"},
+		{"__LEADING__AND__DOUBLE_", "LeadingAndDouble"},
+		{"APP.NAME", "AppName"},
+		{"FEATURE-FLAG", "FeatureFlag"},
+		{"2FA_SECRET", "Env2faSecret"},
+		{"_", "Env"},
+		{"", "Env"},
+	}
+	for _, tt := range tests {
+		if got := FieldName(tt.key); got != tt.want {
+			t.Errorf("FieldName(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEnvFieldCollision(t *testing.T) {
+	tests := []struct {
+		vars []EnvVar
+		want string
+	}{
+		{[]EnvVar{{Key: "APP_NAME"}, {Key: "APP.NAME"}}, "APP.NAME and APP_NAME both become the field AppName"},
+		{[]EnvVar{{Key: "PORT"}, {Key: "port"}}, "PORT and port both become the field Port"},
+		{[]EnvVar{{Key: "APP_NAME"}, {Key: "APP_NAME", Required: true}}, ""},
+	}
+	for _, tt := range tests {
+		_, err := Env("go", "config", tt.vars)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("Env(%v) returned %v", tt.vars, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Env(%v) = %v, want error containing %q", tt.vars, err, tt.want)
+		}
+	}
+}
@@ -13,6 +13,11 @@ func PrintFatal(message any) {
 	fmt.Println()
 	log.Fatalf("(%s) %s", chalk.Red.Color("Error"), chalk.White.Color(fmt.Sprintf("%v", message)))
 }
+
+// Prints an error without exiting EG. for errors that are reported while a command keeps running
+func PrintError(state string, message any) {
+	fmt.Printf("(%s) %s\n", chalk.Red.Color(state), chalk.White.Color(fmt.Sprintf("%v", message)))
+}
 func PrintBlue(message any) {
 	log.Println(chalk.Blue.Color(fmt.Sprintf("%v", message)))
 }
@@ -5,7 +5,6 @@ import (
 	"sync"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/errdefs"
 	v1 "github.com/opencontainers/image-spec/specs-go/v1"
@@ -43,19 +42,38 @@ func createContainer(ctx context.Context, client *client.Client, wg *sync.WaitGr
 
 	c := cc.GetContainer(ctx)
 
+	//pins the image by digest so that recreating the container later on uses the exact same image
+	if c.ImageDigest == "" {
+		digest, err := resolveImageDigest(ctx, client, c.Image)
+		if err != nil {
+			return exit(wg, cc.Callback(ctx, err))
+		}
+		c.ImageDigest = digest
+	}
+
 	body, err := client.ContainerCreate(
 		ctx,
 		&container.Config{
-			Image:        c.Image,
+			Image:        c.ImageRef(),
 			Hostname:     c.Hostname,
 			ExposedPorts: c.CreateNatExposedPortSet(),
 			Env:          c.CreateENVKeyPair(),
+			Cmd:          c.CreateCmd(),
+			Entrypoint:   c.Entrypoint,
+			WorkingDir:   c.WorkingDir,
+			User:         c.User,
+			Labels:       c.CreateLabels(),
 		},
 		&container.HostConfig{
-			PortBindings: c.CreatePortBindings(),
-			Mounts:       c.CreateMounts(),
+			PortBindings:  c.CreatePortBindings(),
+			Resources:     c.CreateResources(),
+			Mounts:        c.CreateMounts(),
+			NetworkMode:   container.NetworkMode(c.Network),
+			AutoRemove:    c.AutoRemove,
+			RestartPolicy: c.CreateRestartPolicy(),
+			ExtraHosts:    c.CreateExtraHosts(),
 		},
-		&network.NetworkingConfig{},
+		c.CreateNetworkingConfig(),
 		&v1.Platform{},
 		c.Name,
 	)
@@ -0,0 +1,81 @@
+package containers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// A state change of a container EG. start, die, oom
+type ContainerEvent struct {
+	ID     string
+	Name   string
+	Action string
+	// Only set for die events
+	ExitCode string
+	Time     time.Time
+}
+
+type ContainerEventWatcher interface {
+	// Names or IDs of the containers to receive events of
+	GetContainers() []string
+	Events() chan<- ContainerEvent
+	Callback(ctx context.Context, err error) error
+}
+
+type containerEventsPayload struct {
+	containers []string
+	events     chan<- ContainerEvent
+	cb         CallbackFn
+}
+
+func (p containerEventsPayload) GetContainers() []string       { return p.containers }
+func (p containerEventsPayload) Events() chan<- ContainerEvent { return p.events }
+func (p containerEventsPayload) Callback(ctx context.Context, err error) error {
+	return p.cb(ctx, err)
+}
+
+// Sends the state changes of the containers to events until ctx is canceled,
+// canceling is not passed to the callback as an error
+func NewContainerEventsPayload(containers []string, events chan<- ContainerEvent, cb CallbackFn) ContainerEventWatcher {
+	if cb == nil {
+		return containerEventsPayload{
+			containers: containers,
+			events:     events,
+			cb:         func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return containerEventsPayload{containers: containers, events: events, cb: cb}
+}
+
+func watchContainerEvents(ctx context.Context, client *client.Client, wg *sync.WaitGroup, w ContainerEventWatcher) int {
+	args := filters.NewArgs(filters.Arg("type", events.ContainerEventType))
+	for _, container := range w.GetContainers() {
+		args.Add("container", container)
+	}
+	messages, errs := client.Events(ctx, types.EventsOptions{Filters: args})
+	for {
+		select {
+		case msg := <-messages:
+			event := ContainerEvent{
+				ID:       msg.Actor.ID,
+				Name:     msg.Actor.Attributes["name"],
+				Action:   msg.Action,
+				ExitCode: msg.Actor.Attributes["exitCode"],
+				Time:     time.Unix(0, msg.TimeNano),
+			}
+			select {
+			case w.Events() <- event:
+			case <-ctx.Done():
+				return exit(wg, w.Callback(ctx, nil))
+			}
+		case err := <-errs:
+			return exit(wg, w.Callback(ctx, ignoreCanceled(ctx, err)))
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package containers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type execKey int
+
+var resultKey execKey
+
+// Output and exit code of a command executed inside a container
+type ExecResult struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+}
+
+type ContainerExecOptions struct {
+	ID  string
+	Cmd []string
+}
+
+type ContainerExecer interface {
+	GetExecOptions() ContainerExecOptions
+	Callback(ctx context.Context, err error) error
+}
+
+type containerExecPayload struct {
+	options ContainerExecOptions
+	cb      CallbackFn
+}
+
+func (p containerExecPayload) GetExecOptions() ContainerExecOptions { return p.options }
+func (p containerExecPayload) Callback(ctx context.Context, err error) error {
+	return p.cb(ctx, err)
+}
+
+// Runs cmd inside a running container. The result is passed to the callback via context see FromExecContext,
+// a non zero exit code is passed as an ErrExecFailed error and a stopped container as an ErrContainerNotRunning error
+func NewContainerExecPayload(ID string, cmd []string, cb CallbackFn) ContainerExecer {
+	if cb == nil {
+		return containerExecPayload{
+			options: ContainerExecOptions{ID: ID, Cmd: cmd},
+			cb:      func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return containerExecPayload{options: ContainerExecOptions{ID: ID, Cmd: cmd}, cb: cb}
+}
+
+// Retrieves the exec result from its context
+func FromExecContext(ctx context.Context) (*ExecResult, bool) {
+	result, ok := ctx.Value(resultKey).(*ExecResult)
+	return result, ok
+}
+
+func execContainer(ctx context.Context, client *client.Client, wg *sync.WaitGroup, c ContainerExecer) int {
+	opt := c.GetExecOptions()
+	created, err := client.ContainerExecCreate(ctx, opt.ID, types.ExecConfig{
+		Cmd:          opt.Cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		if errdefs.IsConflict(err) {
+			return exit(wg, c.Callback(ctx, containerNotRunningError(err)))
+		}
+		return exit(wg, c.Callback(ctx, err))
+	}
+	attached, err := client.ContainerExecAttach(ctx, created.ID, types.ExecStartCheck{})
+	if err != nil {
+		return exit(wg, c.Callback(ctx, err))
+	}
+	defer attached.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attached.Reader); err != nil {
+		return exit(wg, c.Callback(ctx, err))
+	}
+	inspect, err := client.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return exit(wg, c.Callback(ctx, err))
+	}
+
+	result := &ExecResult{ExitCode: inspect.ExitCode, Stdout: stdout.String(), Stderr: stderr.String()}
+	ctx = context.WithValue(ctx, resultKey, result)
+	if result.ExitCode != 0 {
+		err := fmt.Errorf("%v exited with code %d\n%s", opt.Cmd, result.ExitCode, result.Stderr)
+		return exit(wg, c.Callback(ctx, execFailedError(err)))
+	}
+	return exit(wg, c.Callback(ctx, nil))
+}
@@ -0,0 +1,170 @@
+package containers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+)
+
+type containerKey int
+
+var containerInspectKey containerKey
+
+type ContainerInspector interface {
+	GetInspectID() string
+	Callback(ctx context.Context, err error) error
+}
+
+type containerInspectPayload struct {
+	ID       string
+	callback CallbackFn
+}
+
+func (p containerInspectPayload) GetInspectID() string { return p.ID }
+func (p containerInspectPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// The inspect result is passed to the callback via context see FromContainerInspectContext.
+// If the container does not exist the callback receives an ErrContainerNotFound error
+func NewContainerInspectPayload(ID string, cb CallbackFn) ContainerInspector {
+	if cb == nil {
+		return containerInspectPayload{
+			ID:       ID,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return containerInspectPayload{ID: ID, callback: cb}
+}
+
+// Retrieves the container inspect result from its context
+func FromContainerInspectContext(ctx context.Context) (*types.ContainerJSON, bool) {
+	inspect, ok := ctx.Value(containerInspectKey).(*types.ContainerJSON)
+	return inspect, ok
+}
+
+// Returns the environment of an inspected container as a map
+func InspectEnv(inspect *types.ContainerJSON) map[string]string {
+	env := make(map[string]string)
+	if inspect.Config == nil {
+		return env
+	}
+	for _, pair := range inspect.Config.Env {
+		key, value, _ := strings.Cut(pair, "=")
+		env[key] = value
+	}
+	return env
+}
+
+func inspectContainer(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ContainerInspector) int {
+	inspect, err := client.ContainerInspect(ctx, p.GetInspectID())
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return exit(wg, p.Callback(ctx, containerNotFoundError(err)))
+		}
+		return exit(wg, p.Callback(ctx, err))
+	}
+	ctx = context.WithValue(ctx, containerInspectKey, &inspect)
+	return exit(wg, p.Callback(ctx, nil))
+}
+
+// Rebuilds the configuration of a container blah created from its inspect result and the labels set at create.
+// Settings that match the defaults of its image are left out so they are not persisted as if blah set them
+func FromInspect(inspect *types.ContainerJSON, image *types.ImageInspect) Container {
+	c := Container{
+		ContainerID: inspect.ID,
+		Name:        strings.TrimPrefix(inspect.Name, "/"),
+	}
+	imageConfig := &container.Config{}
+	if image != nil && image.Config != nil {
+		imageConfig = image.Config
+	}
+	if config := inspect.Config; config != nil {
+		c.Project = config.Labels[LabelProject]
+		c.Service = config.Labels[LabelService]
+		c.Profile = config.Labels[LabelProfile]
+		c.BuildContext = config.Labels[LabelBuildContext]
+		c.Image = config.Labels[LabelImage]
+		if c.Image == "" {
+			c.Image = config.Image
+		}
+		if named, err := reference.ParseNormalizedNamed(config.Image); err == nil {
+			if canonical, ok := named.(reference.Canonical); ok {
+				c.ImageDigest = canonical.Digest().String()
+			}
+		}
+		c.Hostname = config.Hostname
+		if config.WorkingDir != imageConfig.WorkingDir {
+			c.WorkingDir = config.WorkingDir
+		}
+		if config.User != imageConfig.User {
+			c.User = config.User
+		}
+		imageEnv := make(map[string]bool)
+		for _, pair := range imageConfig.Env {
+			imageEnv[pair] = true
+		}
+		for _, pair := range config.Env {
+			if key, value, ok := strings.Cut(pair, "="); ok && !imageEnv[pair] {
+				c.Env = append(c.Env, ContainerEnv{Key: key, Value: value})
+			}
+		}
+		if strings.Join(config.Cmd, "\x00") != strings.Join(imageConfig.Cmd, "\x00") {
+			for _, arg := range config.Cmd {
+				c.Cmd = append(c.Cmd, ContainerCmdArg{Arg: arg})
+			}
+		}
+		var ports []string
+		for port := range config.ExposedPorts {
+			ports = append(ports, string(port))
+		}
+		sort.Strings(ports)
+		for _, port := range ports {
+			c.ExposedPorts = append(c.ExposedPorts, ContainerExposedPort{Port: storedPort(port)})
+		}
+	}
+	if host := inspect.HostConfig; host != nil {
+		if host.NetworkMode.IsUserDefined() {
+			c.Network = string(host.NetworkMode)
+		}
+		c.AutoRemove = host.AutoRemove
+		if host.RestartPolicy.Name != "" && host.RestartPolicy.Name != string(RestartNo) {
+			c.Restart = RestartPolicy(host.RestartPolicy.Name)
+		}
+		c.Resources = ContainerResources{Memory: host.Memory, NanoCPUs: host.NanoCPUs}
+		for _, extraHost := range host.ExtraHosts {
+			c.ExtraHosts = append(c.ExtraHosts, ContainerExtraHost{Host: extraHost})
+		}
+		for _, m := range host.Mounts {
+			c.Mounts = append(c.Mounts, ContainerMount{Type: m.Type, Source: m.Source, Tagret: m.Target})
+		}
+		var ports []string
+		for port := range host.PortBindings {
+			ports = append(ports, string(port))
+		}
+		sort.Strings(ports)
+		for _, port := range ports {
+			for _, binding := range host.PortBindings[nat.Port(port)] {
+				c.PortBindings = append(c.PortBindings, ContainerPortBinding{
+					Port:     storedPort(port),
+					HostPort: binding.HostPort,
+					HostIP:   binding.HostIP,
+				})
+			}
+		}
+	}
+	return c
+}
+
+// Ports are stored without the default tcp protocol EG. 27017 for 27017/tcp
+func storedPort(port string) string {
+	return strings.TrimSuffix(port, "/tcp")
+}
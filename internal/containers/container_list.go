@@ -0,0 +1,63 @@
+package containers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+var containerListKey containerKey = 1
+
+type ContainerLister interface {
+	GetListLabels() map[string]string
+	Callback(ctx context.Context, err error) error
+}
+
+type containerListPayload struct {
+	labels   map[string]string
+	callback CallbackFn
+}
+
+func (p containerListPayload) GetListLabels() map[string]string { return p.labels }
+func (p containerListPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Lists running and stopped containers that have every label EG. io.blah.project=myproj, an empty value
+// only requires the label to be present. The containers are passed to the callback via context
+// see FromContainerListContext
+func NewContainerListPayload(labels map[string]string, cb CallbackFn) ContainerLister {
+	if cb == nil {
+		return containerListPayload{
+			labels:   labels,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return containerListPayload{labels: labels, callback: cb}
+}
+
+// Retrieves the listed containers from its context
+func FromContainerListContext(ctx context.Context) ([]types.Container, bool) {
+	list, ok := ctx.Value(containerListKey).([]types.Container)
+	return list, ok
+}
+
+func listContainers(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ContainerLister) int {
+	args := filters.NewArgs()
+	for key, value := range p.GetListLabels() {
+		if value == "" {
+			args.Add("label", key)
+			continue
+		}
+		args.Add("label", key+"="+value)
+	}
+	list, err := client.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+	if err != nil {
+		return exit(wg, p.Callback(ctx, err))
+	}
+	ctx = context.WithValue(ctx, containerListKey, list)
+	return exit(wg, p.Callback(ctx, nil))
+}
@@ -0,0 +1,85 @@
+package containers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type ContainerLogOptions struct {
+	ID string
+	// Keep streaming new output until the container stops or the context is canceled
+	Follow bool
+	// Only output since a timestamp or relative time EG. 10s
+	Since string
+	// Number of lines to show from the end of the logs EG. 50 or all
+	Tail string
+}
+
+type ContainerLogger interface {
+	GetLogOptions() ContainerLogOptions
+	Callback(ctx context.Context, err error) error
+	// Receives the stdout of the container
+	Stdout() io.Writer
+	// Receives the stderr of the container
+	Stderr() io.Writer
+}
+
+type containerLogPayload struct {
+	options ContainerLogOptions
+	stdout  io.Writer
+	stderr  io.Writer
+	cb      CallbackFn
+}
+
+func (p containerLogPayload) GetLogOptions() ContainerLogOptions { return p.options }
+func (p containerLogPayload) Stdout() io.Writer                  { return p.stdout }
+func (p containerLogPayload) Stderr() io.Writer                  { return p.stderr }
+func (p containerLogPayload) Callback(ctx context.Context, err error) error {
+	return p.cb(ctx, err)
+}
+
+// Copies the logs of a container to stdout and stderr. With options.Follow the logs are streamed
+// until the container stops or ctx is canceled, canceling is not passed to the callback as an error
+func NewContainerLogPayload(options ContainerLogOptions, stdout io.Writer, stderr io.Writer, cb CallbackFn) ContainerLogger {
+	if cb == nil {
+		return containerLogPayload{
+			options: options,
+			stdout:  stdout,
+			stderr:  stderr,
+			cb:      func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return containerLogPayload{options: options, stdout: stdout, stderr: stderr, cb: cb}
+}
+
+func logContainer(ctx context.Context, client *client.Client, wg *sync.WaitGroup, c ContainerLogger) int {
+	opt := c.GetLogOptions()
+	body, err := client.ContainerLogs(ctx, opt.ID, types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opt.Follow,
+		Since:      opt.Since,
+		Tail:       opt.Tail,
+	})
+	if err != nil {
+		return exit(wg, c.Callback(ctx, ignoreCanceled(ctx, err)))
+	}
+	defer body.Close()
+	//containers are created without a tty so stdout and stderr are multiplexed
+	_, err = stdcopy.StdCopy(c.Stdout(), c.Stderr(), body)
+	return exit(wg, c.Callback(ctx, ignoreCanceled(ctx, err)))
+}
+
+// Streams are ended by canceling their context which is not an error
+func ignoreCanceled(ctx context.Context, err error) error {
+	if err != nil && (ctx.Err() != nil || errors.Is(err, context.Canceled)) {
+		return nil
+	}
+	return err
+}
@@ -30,11 +30,17 @@ func (c containerStopperPayload) Callback(ctx context.Context, err error) error
 
 //Tries to get the container id from context if ID  param is nil
 func NewContainerStopperPayload(ID *string, cb CallbackFn) ContainerStopper {
+	if cb == nil {
+		return containerStopperPayload{
+			ID:       ID,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
 	return containerStopperPayload{ID: ID, callback: cb}
 
 }
 
 //Stops a running container
 func stopContainer(ctx context.Context, client *client.Client, wg *sync.WaitGroup, c ContainerStopper) int {
-	return exit(wg, client.ContainerStop(ctx, c.GetContainerID(ctx), nil))
+	return exit(wg, c.Callback(ctx, client.ContainerStop(ctx, c.GetContainerID(ctx), nil)))
 }
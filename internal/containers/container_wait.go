@@ -0,0 +1,53 @@
+package containers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+type ContainerWaiter interface {
+	GetWaitID() string
+	Callback(ctx context.Context, err error) error
+}
+
+type containerWaitPayload struct {
+	ID       string
+	callback CallbackFn
+}
+
+func (p containerWaitPayload) GetWaitID() string { return p.ID }
+func (p containerWaitPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Waits until a container exits, the callback receives an error if it exited with a non zero code
+func NewContainerWaitPayload(ID string, cb CallbackFn) ContainerWaiter {
+	if cb == nil {
+		return containerWaitPayload{
+			ID:       ID,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return containerWaitPayload{ID: ID, callback: cb}
+}
+
+func waitContainer(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ContainerWaiter) int {
+	statusCh, errCh := client.ContainerWait(ctx, p.GetWaitID(), container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return exit(wg, p.Callback(ctx, err))
+	case status := <-statusCh:
+		if status.Error != nil {
+			return exit(wg, p.Callback(ctx, errors.New(status.Error.Message)))
+		}
+		if status.StatusCode != 0 {
+			return exit(wg, p.Callback(ctx, fmt.Errorf("Container %s exited with code %d", p.GetWaitID(), status.StatusCode)))
+		}
+		return exit(wg, p.Callback(ctx, nil))
+	}
+}
@@ -53,9 +53,60 @@ func (c *Controller) Start(ctx context.Context, command any) *sync.WaitGroup {
 	case ContainerStopper:
 		go stopContainer(ctx, c.client, &wg, command.(ContainerStopper))
 		break
+	case ContainerExecer:
+		go execContainer(ctx, c.client, &wg, command.(ContainerExecer))
+		break
+	case ContainerLister:
+		go listContainers(ctx, c.client, &wg, command.(ContainerLister))
+		break
+	case ContainerInspector:
+		go inspectContainer(ctx, c.client, &wg, command.(ContainerInspector))
+		break
+	case ContainerWaiter:
+		go waitContainer(ctx, c.client, &wg, command.(ContainerWaiter))
+		break
+	case ContainerLogger:
+		go logContainer(ctx, c.client, &wg, command.(ContainerLogger))
+		break
+	case ContainerEventWatcher:
+		go watchContainerEvents(ctx, c.client, &wg, command.(ContainerEventWatcher))
+		break
+	case NetworkCreator:
+		go createNetwork(ctx, c.client, &wg, command.(NetworkCreator))
+		break
+	case VolumeCreator:
+		go createVolume(ctx, c.client, &wg, command.(VolumeCreator))
+		break
+	case VolumeRemover:
+		go removeVolume(ctx, c.client, &wg, command.(VolumeRemover))
+		break
+	case VolumeInspector:
+		go inspectVolume(ctx, c.client, &wg, command.(VolumeInspector))
+		break
+	case VolumeLister:
+		go listVolumes(ctx, c.client, &wg, command.(VolumeLister))
+		break
+	case ImageBuilder:
+		go buildImage(ctx, c.client, &wg, command.(ImageBuilder))
+		break
 	case ImagePuller:
 		go pullImage(ctx, c.client, &wg, command.(ImagePuller))
 		break
+	case ImageInspector:
+		go inspectImage(ctx, c.client, &wg, command.(ImageInspector))
+		break
+	case ImageLister:
+		go listImages(ctx, c.client, &wg, command.(ImageLister))
+		break
+	case ImageSaver:
+		go saveImages(ctx, c.client, &wg, command.(ImageSaver))
+		break
+	case ImageLoader:
+		go loadImages(ctx, c.client, &wg, command.(ImageLoader))
+		break
+	case DistributionInspector:
+		go inspectDistribution(ctx, c.client, &wg, command.(DistributionInspector))
+		break
 	default:
 		color.PrintFatal(fmt.Errorf("%T Is not a valid container command", command))
 	}
@@ -65,3 +65,95 @@ func needContainerReCreate(err error) error {
 	}
 	return errNeedContainerReCreate{err}
 }
+
+// ErrImageNotFound indicates the image is not present locally
+type ErrImageNotFound interface{ ImageNotFound() }
+type errImageNotFound struct{ error }
+
+func (e errImageNotFound) ImageNotFound() error { return e.error }
+func IsErrImageNotFound(err error) bool         { _, is := err.(errImageNotFound); return is }
+func imageNotFoundError(err error) error {
+	if err == nil || IsErrImageNotFound(err) {
+		return err
+	}
+	return errImageNotFound{err}
+}
+
+// ErrImageUnauthorized indicates the registry refused access to the image, it is private,
+// the credentials are missing or the pull rate limit was reached
+type ErrImageUnauthorized interface{ ImageUnauthorized() }
+type errImageUnauthorized struct{ error }
+
+func (e errImageUnauthorized) ImageUnauthorized() error { return e.error }
+func IsErrImageUnauthorized(err error) bool             { _, is := err.(errImageUnauthorized); return is }
+func imageUnauthorizedError(err error) error {
+	if err == nil || IsErrImageUnauthorized(err) {
+		return err
+	}
+	return errImageUnauthorized{err}
+}
+
+// ErrExecFailed indicates a command executed inside a container exited with a non zero code
+type ErrExecFailed interface{ ExecFailed() }
+type errExecFailed struct{ error }
+
+func (e errExecFailed) ExecFailed() error { return e.error }
+func IsErrExecFailed(err error) bool      { _, is := err.(errExecFailed); return is }
+func execFailedError(err error) error {
+	if err == nil || IsErrExecFailed(err) {
+		return err
+	}
+	return errExecFailed{err}
+}
+
+// ErrContainerNotRunning indicates the container has to be running for the command EG. exec
+type ErrContainerNotRunning interface{ ContainerNotRunning() }
+type errContainerNotRunning struct{ error }
+
+func (e errContainerNotRunning) ContainerNotRunning() error { return e.error }
+func IsErrContainerNotRunning(err error) bool               { _, is := err.(errContainerNotRunning); return is }
+func containerNotRunningError(err error) error {
+	if err == nil || IsErrContainerNotRunning(err) {
+		return err
+	}
+	return errContainerNotRunning{err}
+}
+
+// ErrNetworkExists indicates a network with the same name already exists
+type ErrNetworkExists interface{ NetworkExists() }
+type errNetworkExists struct{ error }
+
+func (e errNetworkExists) NetworkExists() error { return e.error }
+func IsErrNetworkExists(err error) bool         { _, is := err.(errNetworkExists); return is }
+func networkExistsError(err error) error {
+	if err == nil || IsErrNetworkExists(err) {
+		return err
+	}
+	return errNetworkExists{err}
+}
+
+// ErrContainerNotFound indicates the container does not exist, it may have been removed outside of blah
+type ErrContainerNotFound interface{ ContainerNotFound() }
+type errContainerNotFound struct{ error }
+
+func (e errContainerNotFound) ContainerNotFound() error { return e.error }
+func IsErrContainerNotFound(err error) bool             { _, is := err.(errContainerNotFound); return is }
+func containerNotFoundError(err error) error {
+	if err == nil || IsErrContainerNotFound(err) {
+		return err
+	}
+	return errContainerNotFound{err}
+}
+
+// ErrVolumeNotFound indicates the volume does not exist, it may have been removed outside of blah
+type ErrVolumeNotFound interface{ VolumeNotFound() }
+type errVolumeNotFound struct{ error }
+
+func (e errVolumeNotFound) VolumeNotFound() error { return e.error }
+func IsErrVolumeNotFound(err error) bool          { _, is := err.(errVolumeNotFound); return is }
+func volumeNotFoundError(err error) error {
+	if err == nil || IsErrVolumeNotFound(err) {
+		return err
+	}
+	return errVolumeNotFound{err}
+}
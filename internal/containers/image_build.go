@@ -0,0 +1,190 @@
+package containers
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/moby/patternmatcher"
+	"github.com/moby/patternmatcher/ignorefile"
+)
+
+type BuildOptions struct {
+	// Directory that is sent to the docker engine as the build context
+	ContextDir string
+	// Tag of the built image EG. myproj_app:dev
+	Tag string
+}
+
+type ImageBuilder interface {
+	GetBuildOptions() BuildOptions
+	Callback(ctx context.Context, err error) error
+	io.Writer
+}
+
+type imageBuildPayload struct {
+	options  BuildOptions
+	callback CallbackFn
+	writer   io.Writer
+}
+
+func (p imageBuildPayload) GetBuildOptions() BuildOptions { return p.options }
+func (p imageBuildPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+func (p imageBuildPayload) Write(b []byte) (n int, err error) {
+	return p.writer.Write(b)
+}
+
+// Builds the Dockerfile of the context directory, the output of every step is written to writer
+func NewImageBuildPayload(options BuildOptions, writer io.Writer, cb CallbackFn) ImageBuilder {
+	if cb == nil {
+		return imageBuildPayload{
+			options:  options,
+			callback: func(ctx context.Context, err error) error { return err },
+			writer:   writer,
+		}
+	}
+	return imageBuildPayload{options: options, callback: cb, writer: writer}
+}
+
+func buildImage(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ImageBuilder) int {
+	opt := p.GetBuildOptions()
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarContext(opt.ContextDir, pw))
+	}()
+	resp, err := client.ImageBuild(ctx, pr, types.ImageBuildOptions{
+		Tags:        []string{opt.Tag},
+		Dockerfile:  "Dockerfile",
+		Remove:      true,
+		ForceRemove: true,
+	})
+	if err != nil {
+		pr.CloseWithError(err)
+		return exit(wg, p.Callback(ctx, err))
+	}
+	defer resp.Body.Close()
+	return exit(wg, p.Callback(ctx, decodeBuildStream(resp.Body, p)))
+}
+
+// Writes the output of the Dockerfile steps to w, a failing step is returned as error
+func decodeBuildStream(r io.Reader, w io.Writer) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var msg ImagePullResponse
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg.ErrorMessage != "" {
+			return errors.New(strings.TrimSpace(msg.ErrorMessage))
+		}
+		if msg.Stream != "" {
+			if _, err := io.WriteString(w, msg.Stream); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Writes the files of dir as a tar stream skipping the patterns of its .dockerignore, the patterns are
+// matched like docker build does including ! exceptions and ** wildcards and symlinks are kept as links
+func tarContext(dir string, w io.Writer) error {
+	patterns, err := dockerignorePatterns(dir)
+	if err != nil {
+		return err
+	}
+	pm, err := patternmatcher.New(patterns)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(w)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		ignored, err := pm.MatchesOrParentMatches(rel)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			if !info.IsDir() {
+				return nil
+			}
+			//an ignored directory is still walked if an exception may match a path inside of it
+			for _, pattern := range pm.Patterns() {
+				if pattern.Exclusion() && strings.HasPrefix(pattern.String()+string(filepath.Separator), rel+string(filepath.Separator)) {
+					return nil
+				}
+			}
+			return filepath.SkipDir
+		}
+		var link string
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular() && !info.IsDir():
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// Reads the patterns of the .dockerignore of dir. Like the docker cli the Dockerfile and the
+// .dockerignore are always sent because the engine needs them for the build
+func dockerignorePatterns(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	patterns, err := ignorefile.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	return append(patterns, "!Dockerfile", "!.dockerignore"), nil
+}
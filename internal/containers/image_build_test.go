@@ -0,0 +1,101 @@
+package containers
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestTarContext(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Dockerfile":                    "FROM golang",
+		"main.go":                       "package main",
+		"debug.log":                     "",
+		"node_modules/pkg/index.js":     "",
+		"build/out.bin":                 "",
+		"build/keep.txt":                "",
+		"config/secret.txt":             "",
+		"config/deep/nested/secret.txt": "",
+		"config/app.yaml":               "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("config/app.yaml", filepath.Join(dir, "app.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	ignore := "# comment\n*.log\nnode_modules\nbuild\n!build/keep.txt\n**/secret.txt\nDockerfile\n.dockerignore\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, ".dockerignore"), []byte(ignore), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := tarContext(dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	links := make(map[string]string)
+	tr := tar.NewReader(&buf)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, header.Name)
+		if header.Typeflag == tar.TypeSymlink {
+			links[header.Name] = header.Linkname
+		}
+	}
+	sort.Strings(got)
+	want := []string{
+		".dockerignore",
+		"Dockerfile",
+		"app.yaml",
+		"build/keep.txt",
+		"config",
+		"config/app.yaml",
+		"config/deep",
+		"config/deep/nested",
+		"main.go",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tarContext() wrote\n%q\nwant\n%q", got, want)
+	}
+	if links["app.yaml"] != "config/app.yaml" {
+		t.Errorf("app.yaml links to %q, want config/app.yaml", links["app.yaml"])
+	}
+}
+
+func TestTarContextWithoutDockerignore(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM golang"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tarContext(dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+	header, err := tar.NewReader(&buf).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Name != "Dockerfile" {
+		t.Errorf("got %s, want Dockerfile", header.Name)
+	}
+}
@@ -0,0 +1,179 @@
+package containers
+
+import (
+	"context"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+type imageKey int
+
+var inspectKey imageKey
+
+type ImageInspector interface {
+	GetImageName() string
+	Callback(ctx context.Context, err error) error
+}
+
+type imageInspectPayload struct {
+	name     string
+	callback CallbackFn
+}
+
+func (p imageInspectPayload) GetImageName() string { return p.name }
+func (p imageInspectPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// The inspect result is passed to the callback via context see FromImageInspectContext.
+// If the image does not exist locally the callback receives an ErrImageNotFound error
+func NewImageInspectPayload(name string, cb CallbackFn) ImageInspector {
+	if cb == nil {
+		return imageInspectPayload{
+			name:     name,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return imageInspectPayload{name: name, callback: cb}
+}
+
+// Retrieves the image inspect result from its context
+func FromImageInspectContext(ctx context.Context) (*types.ImageInspect, bool) {
+	inspect, ok := ctx.Value(inspectKey).(*types.ImageInspect)
+	return inspect, ok
+}
+
+// Inspects a local image
+func inspectImage(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ImageInspector) int {
+	inspect, _, err := client.ImageInspectWithRaw(ctx, p.GetImageName())
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return exit(wg, p.Callback(ctx, imageNotFoundError(err)))
+		}
+		return exit(wg, p.Callback(ctx, err))
+	}
+	ctx = context.WithValue(ctx, inspectKey, &inspect)
+	return exit(wg, p.Callback(ctx, nil))
+}
+
+// Checks if an image is present locally
+func imageExists(ctx context.Context, client *client.Client, name string) (bool, error) {
+	_, _, err := client.ImageInspectWithRaw(ctx, name)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Returns the digest of the repository the image reference was pulled from EG. sha256:4200c30...
+// An empty string is returned for images that were never pulled from a registry
+func ImageRepoDigest(inspect *types.ImageInspect, name string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return "", err
+	}
+	for i := range inspect.RepoDigests {
+		repoDigest, err := reference.ParseNormalizedNamed(inspect.RepoDigests[i])
+		if err != nil {
+			continue
+		}
+		canonical, ok := repoDigest.(reference.Canonical)
+		if ok && repoDigest.Name() == named.Name() {
+			return canonical.Digest().String(), nil
+		}
+	}
+	return "", nil
+}
+
+// Returns the version the image was built with EG. 6.0.1 for mongo:latest.
+// Official images set a <REPOSITORY>_VERSION environment variable EG. MONGO_VERSION, which is checked
+// before the OCI version label because images often inherit that label from their base OS EG. 22.04
+func ImageVersion(image string, inspect *types.ImageInspect) string {
+	if inspect.Config == nil {
+		return ""
+	}
+	if key := versionEnvKey(image); key != "" {
+		for _, env := range inspect.Config.Env {
+			if k, value, found := strings.Cut(env, "="); found && k == key {
+				return value
+			}
+		}
+	}
+	return inspect.Config.Labels["org.opencontainers.image.version"]
+}
+
+// Returns the version environment variable of an official image EG. MYSQL_VERSION for mysql:8
+func versionEnvKey(image string) string {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return ""
+	}
+	repository := path.Base(reference.Path(named))
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(repository)) + "_VERSION"
+}
+
+// Returns the major part of a version string EG. 6 for 6.0.1
+func MajorVersion(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
+
+// Resolves the repo digest of a local image, see ImageRepoDigest
+func resolveImageDigest(ctx context.Context, client *client.Client, name string) (string, error) {
+	inspect, _, err := client.ImageInspectWithRaw(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return ImageRepoDigest(&inspect, name)
+}
+
+type DistributionInspector interface {
+	GetDistributionRef() string
+	Callback(ctx context.Context, err error) error
+}
+
+type distributionInspectPayload struct {
+	name     string
+	callback CallbackFn
+}
+
+func (p distributionInspectPayload) GetDistributionRef() string { return p.name }
+func (p distributionInspectPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Asks the registry if the image reference exists without pulling it.
+// If the reference does not exist the callback receives an ErrImageNotFound error,
+// if the registry refuses access it receives an ErrImageUnauthorized error
+func NewDistributionInspectPayload(name string, cb CallbackFn) DistributionInspector {
+	if cb == nil {
+		return distributionInspectPayload{
+			name:     name,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return distributionInspectPayload{name: name, callback: cb}
+}
+
+func inspectDistribution(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p DistributionInspector) int {
+	_, err := client.DistributionInspect(ctx, p.GetDistributionRef(), "")
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return exit(wg, p.Callback(ctx, imageNotFoundError(err)))
+		}
+		//docker hub also answers unknown repositories with unauthorized so this may be a typo as well
+		if errdefs.IsUnauthorized(err) {
+			return exit(wg, p.Callback(ctx, imageUnauthorizedError(err)))
+		}
+	}
+	return exit(wg, p.Callback(ctx, err))
+}
@@ -0,0 +1,114 @@
+package containers
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestImageVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		image  string
+		env    []string
+		labels map[string]string
+		want   string
+	}{
+		{
+			name:  "mongo on ubuntu jammy",
+			image: "mongo:7.0",
+			env: []string{
+				"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+				"GOSU_VERSION=1.17",
+				"JSYAML_VERSION=3.13.1",
+				"JSYAML_CHECKSUM=662e32319bdd378e91f67578e56a34954b0a2e33aca11d70ab9f4826af24b941",
+				"MONGO_PACKAGE=mongodb-org",
+				"MONGO_REPO=repo.mongodb.org",
+				"MONGO_MAJOR=7.0",
+				"MONGO_VERSION=7.0.14",
+				"HOME=/data/db",
+			},
+			labels: map[string]string{
+				"org.opencontainers.image.ref.name": "ubuntu",
+				"org.opencontainers.image.version":  "22.04",
+			},
+			want: "7.0.14",
+		},
+		{
+			name:  "mongo pinned by digest on ubuntu noble",
+			image: "mongo@sha256:4200c3073389d5b303070e53ff8f5e4472efb534340d28599458ccc24f378025",
+			env: []string{
+				"GOSU_VERSION=1.17",
+				"JSYAML_VERSION=3.13.1",
+				"MONGO_MAJOR=8.0",
+				"MONGO_VERSION=8.0.3",
+			},
+			labels: map[string]string{
+				"org.opencontainers.image.ref.name": "ubuntu",
+				"org.opencontainers.image.version":  "24.04",
+			},
+			want: "8.0.3",
+		},
+		{
+			name:  "mysql",
+			image: "mysql:8.0",
+			env: []string{
+				"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+				"GOSU_VERSION=1.17",
+				"MYSQL_MAJOR=8.0",
+				"MYSQL_VERSION=8.0.39-1.el9",
+				"MYSQL_SHELL_VERSION=8.0.38-1.el9",
+			},
+			want: "8.0.39-1.el9",
+		},
+		{
+			name:  "nginx",
+			image: "docker.io/library/nginx:latest",
+			env: []string{
+				"NGINX_VERSION=1.27.1",
+				"NJS_VERSION=0.8.5",
+				"NJS_RELEASE=1~bookworm",
+				"PKG_RELEASE=1~bookworm",
+			},
+			labels: map[string]string{"maintainer": "NGINX Docker Maintainers <docker-maint@nginx.com>"},
+			want:   "1.27.1",
+		},
+		{
+			name:  "redis",
+			image: "redis:7",
+			env:   []string{"GOSU_VERSION=1.17", "REDIS_VERSION=7.4.0"},
+			want:  "7.4.0",
+		},
+		{
+			name:   "label only",
+			image:  "example.com/team/app:1",
+			env:    []string{"GOSU_VERSION=1.17"},
+			labels: map[string]string{"org.opencontainers.image.version": "1.2.3"},
+			want:   "1.2.3",
+		},
+		{
+			name:  "unknown",
+			image: "busybox",
+			env:   []string{"PATH=/bin"},
+			want:  "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspect := &types.ImageInspect{Config: &container.Config{Env: tt.env, Labels: tt.labels}}
+			if got := ImageVersion(tt.image, inspect); got != tt.want {
+				t.Errorf("ImageVersion(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+	tests := map[string]string{"7.0.14": "7", "8.0.39-1.el9": "8", "22.04": "22", "": "", "unknown": "unknown"}
+	for in, want := range tests {
+		if got := MajorVersion(in); got != want {
+			t.Errorf("MajorVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
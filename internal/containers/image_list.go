@@ -0,0 +1,81 @@
+package containers
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+var listKey imageKey = 1
+
+type ImageLister interface {
+	GetRepository() string
+	Callback(ctx context.Context, err error) error
+}
+
+type imageListPayload struct {
+	repository string
+	callback   CallbackFn
+}
+
+func (p imageListPayload) GetRepository() string { return p.repository }
+func (p imageListPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Lists the local images of a repository EG. mongo, the tags are passed to the callback
+// via context see FromImageListContext
+func NewImageListPayload(repository string, cb CallbackFn) ImageLister {
+	if cb == nil {
+		return imageListPayload{
+			repository: repository,
+			callback:   func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return imageListPayload{repository: repository, callback: cb}
+}
+
+// Retrieves the sorted local tags of the listed repository from its context
+func FromImageListContext(ctx context.Context) ([]string, bool) {
+	tags, ok := ctx.Value(listKey).([]string)
+	return tags, ok
+}
+
+// Returns the repository of an image reference EG. mongo for mongo:6.0
+func ImageRepository(name string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return "", err
+	}
+	return reference.FamiliarName(named), nil
+}
+
+func listImages(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ImageLister) int {
+	repository := p.GetRepository()
+	summaries, err := client.ImageList(ctx, types.ImageListOptions{
+		Filters: filters.NewArgs(filters.Arg("reference", repository)),
+	})
+	if err != nil {
+		return exit(wg, p.Callback(ctx, err))
+	}
+	var tags []string
+	for i := range summaries {
+		for _, repoTag := range summaries[i].RepoTags {
+			named, err := reference.ParseNormalizedNamed(repoTag)
+			if err != nil {
+				continue
+			}
+			if tagged, ok := named.(reference.Tagged); ok && reference.FamiliarName(named) == repository {
+				tags = append(tags, tagged.Tag())
+			}
+		}
+	}
+	sort.Strings(tags)
+	ctx = context.WithValue(ctx, listKey, tags)
+	return exit(wg, p.Callback(ctx, nil))
+}
@@ -0,0 +1,52 @@
+package containers
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/client"
+)
+
+type ImageLoader interface {
+	GetBundle() io.Reader
+	Callback(ctx context.Context, err error) error
+	io.Writer
+}
+
+type imageLoadPayload struct {
+	bundle   io.Reader
+	callback CallbackFn
+	writer   io.Writer
+}
+
+func (p imageLoadPayload) GetBundle() io.Reader { return p.bundle }
+func (p imageLoadPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+func (p imageLoadPayload) Write(b []byte) (n int, err error) {
+	return p.writer.Write(b)
+}
+
+// Loads the images of a tar bundle created by an ImageSaver, the load progress
+// is written to writer in the same JSON stream format as an image pull
+func NewImageLoadPayload(bundle io.Reader, writer io.Writer, cb CallbackFn) ImageLoader {
+	if cb == nil {
+		return imageLoadPayload{
+			bundle:   bundle,
+			callback: func(ctx context.Context, err error) error { return err },
+			writer:   writer,
+		}
+	}
+	return imageLoadPayload{bundle: bundle, callback: cb, writer: writer}
+}
+
+func loadImages(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ImageLoader) int {
+	resp, err := client.ImageLoad(ctx, p.GetBundle(), false)
+	if err != nil {
+		return exit(wg, p.Callback(ctx, err))
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(p, resp.Body)
+	return exit(wg, p.Callback(ctx, err))
+}
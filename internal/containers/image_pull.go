@@ -4,51 +4,100 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
 	"sync"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
-	"github.com/isolateminds/blah/internal/color"
 )
 
 type Image struct {
 	Name string `json:"name"`
 }
 
-var DefaultImagePullWriter = &ImagePullResponse{}
+// Decides when an image is pulled from the registry
+type PullPolicy string
 
+const (
+	// Always pulls the image even if it is present locally
+	PullAlways PullPolicy = "always"
+	// Only pulls the image if it is not present locally
+	PullMissing PullPolicy = "missing"
+	// Never pulls the image, it has to be present locally
+	PullNever PullPolicy = "never"
+)
+
+// Parses a pull policy flag value
+func ParsePullPolicy(policy string) (PullPolicy, error) {
+	switch p := PullPolicy(policy); p {
+	case PullAlways, PullMissing, PullNever:
+		return p, nil
+	}
+	return "", fmt.Errorf("Invalid pull policy %q should be one of always, missing or never", policy)
+}
+
+// A single JSON message of the docker image pull (or load) stream
 type ImagePullResponse struct {
 	Id             string         `json:"id"`
 	Status         string         `json:"status"`
+	Stream         string         `json:"stream"`
 	Progress       string         `json:"progress"`
 	ProgressDetail ProgressDetail `json:"progressDetail"`
-	io.Writer
+	ErrorMessage   string         `json:"error"`
 }
 type ProgressDetail struct {
-	Current int `json:"current"`
-	Total   int `json:"total"`
+	Current int64 `json:"current"`
+	Total   int64 `json:"total"`
 }
 
-func (ip *ImagePullResponse) Write(p []byte) (n int, err error) {
-	jd := json.NewDecoder(bytes.NewReader(p))
-	defer func(status string, progress string) {
-		if status != ip.Status && status != "" {
-			color.PrintStatusWithNewLine(status, progress)
-		}
-	}(ip.Status, ip.Progress)
+// Decodes the docker pull stream of a single image, a Write call may hold
+// several JSON messages or only part of one so incomplete bytes are buffered
+// until the rest of the message arrives
+type imagePullStreamWriter struct {
+	image    string
+	progress *PullProgress
+	buf      []byte
+}
 
-	return len(p), jd.Decode(ip)
+func (w *imagePullStreamWriter) Write(p []byte) (n int, err error) {
+	w.buf = append(w.buf, p...)
+	dec := json.NewDecoder(bytes.NewReader(w.buf))
+	var offset int64
+	for {
+		var msg ImagePullResponse
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return 0, err
+		}
+		offset = dec.InputOffset()
+		if msg.ErrorMessage != "" {
+			return 0, fmt.Errorf("Could not pull %s: %s", w.image, msg.ErrorMessage)
+		}
+		//image loads report what was loaded as a stream message EG. Loaded image: mongo:latest
+		if msg.Status == "" && msg.Stream != "" {
+			msg.Status = strings.TrimSpace(msg.Stream)
+		}
+		w.progress.update(w.image, msg)
+	}
+	w.buf = w.buf[offset:]
+	return len(p), nil
 }
 
 type ImagePuller interface {
 	GetImage() Image
+	GetPullPolicy() PullPolicy
 	Callback(ctx context.Context, err error) error
 	io.Writer
 }
 
 type imagePullPayload struct {
 	image    *Image
+	policy   PullPolicy
 	callback CallbackFn
 	writer   io.Writer
 }
@@ -56,6 +105,9 @@ type imagePullPayload struct {
 func (imp imagePullPayload) GetImage() Image {
 	return *imp.image
 }
+func (imp imagePullPayload) GetPullPolicy() PullPolicy {
+	return imp.policy
+}
 func (imp imagePullPayload) Callback(ctx context.Context, err error) error {
 	return imp.callback(ctx, err)
 }
@@ -63,27 +115,55 @@ func (imp imagePullPayload) Write(b []byte) (n int, err error) {
 	return imp.writer.Write(b)
 }
 
-func NewImagePullPayload(image *Image, writer io.Writer, cb CallbackFn) ImagePuller {
+func NewImagePullPayload(image *Image, policy PullPolicy, writer io.Writer, cb CallbackFn) ImagePuller {
 	if cb == nil {
 		return imagePullPayload{
 			image:    image,
+			policy:   policy,
 			callback: func(ctx context.Context, err error) error { return err },
 			writer:   writer,
 		}
 	}
 	return imagePullPayload{
 		image:    image,
+		policy:   policy,
 		callback: cb,
 		writer:   writer,
 	}
 }
 
+// Pulls an image according to its pull policy. Skipped pulls are reported to the writer
+// as a status message so they show up in the pull progress like any other image
 func pullImage(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ImagePuller) int {
 	image := p.GetImage()
+	policy := p.GetPullPolicy()
+	if policy != PullAlways {
+		exists, err := imageExists(ctx, client, image.Name)
+		if err != nil {
+			return exit(wg, p.Callback(ctx, err))
+		}
+		if exists {
+			return exit(wg, p.Callback(ctx, writePullStatus(p, "Image is present locally, skipping pull")))
+		}
+		if policy == PullNever {
+			err := fmt.Errorf("Image %s is not present locally and the pull policy is %s", image.Name, policy)
+			return exit(wg, p.Callback(ctx, imageNotFoundError(err)))
+		}
+	}
 	rc, err := client.ImagePull(ctx, image.Name, types.ImagePullOptions{})
 	if err != nil {
-		return exit(wg, err)
+		return exit(wg, p.Callback(ctx, err))
 	}
+	defer rc.Close()
 	_, err = io.Copy(p, rc)
-	return exit(wg, err)
+	return exit(wg, p.Callback(ctx, err))
+}
+
+func writePullStatus(w io.Writer, status string) error {
+	b, err := json.Marshal(ImagePullResponse{Status: status})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
 }
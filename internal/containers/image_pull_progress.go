@@ -0,0 +1,161 @@
+package containers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"golang.org/x/term"
+)
+
+const (
+	progressBarWidth   = 30
+	progressRedrawRate = 100 * time.Millisecond
+)
+
+// Progress of a single layer (or the image itself when the message has no layer id)
+type layerProgress struct {
+	image   string
+	id      string
+	status  string
+	current int64
+	total   int64
+	started time.Time
+}
+
+// Renders the pull progress of one or more images that are being pulled concurrently.
+// On a terminal every layer gets its own progress bar that is redrawn in place,
+// otherwise a plain line is printed whenever the status of a layer changes.
+type PullProgress struct {
+	mu       sync.Mutex
+	out      io.Writer
+	tty      bool
+	layers   []*layerProgress
+	index    map[string]*layerProgress
+	drawn    int
+	lastDraw time.Time
+}
+
+// Creates a PullProgress that writes to out, progress bars are only drawn when out is a terminal
+func NewPullProgress(out *os.File) *PullProgress {
+	return &PullProgress{
+		out:   out,
+		tty:   term.IsTerminal(int(out.Fd())),
+		index: make(map[string]*layerProgress),
+	}
+}
+
+// Returns a writer that decodes the docker pull stream of image into the progress display
+func (p *PullProgress) Writer(image string) io.Writer {
+	return &imagePullStreamWriter{image: image, progress: p}
+}
+
+// Draws the final state of all layers
+func (p *PullProgress) Flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tty {
+		p.redraw()
+	}
+}
+
+func (p *PullProgress) update(image string, msg ImagePullResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// messages without a layer id are about the image itself e.g. "Pulling from library/mongo"
+	key := image + "/" + msg.Id
+	if msg.Id == "" || strings.HasPrefix(msg.Status, "Pulling from") {
+		key = image
+	}
+	layer, ok := p.index[key]
+	if !ok {
+		layer = &layerProgress{image: image, id: msg.Id, started: time.Now()}
+		if key == image {
+			layer.id = ""
+		}
+		p.index[key] = layer
+		p.layers = append(p.layers, layer)
+	}
+	statusChanged := layer.status != msg.Status
+	layer.status = msg.Status
+	if msg.ProgressDetail.Total > 0 {
+		if layer.total != msg.ProgressDetail.Total {
+			// the same layer goes through downloading and extracting so restart the clock
+			layer.started = time.Now()
+		}
+		layer.current = msg.ProgressDetail.Current
+		layer.total = msg.ProgressDetail.Total
+	} else if statusChanged {
+		layer.current, layer.total = 0, 0
+	}
+
+	if !p.tty {
+		if statusChanged {
+			fmt.Fprintln(p.out, layer.plainLine())
+		}
+		return
+	}
+	if statusChanged || time.Since(p.lastDraw) >= progressRedrawRate {
+		p.redraw()
+	}
+}
+
+// Moves the cursor back to the first line that was drawn and redraws every layer
+func (p *PullProgress) redraw() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.out, "\x1b[%dA", p.drawn)
+	}
+	for i := range p.layers {
+		fmt.Fprintf(p.out, "\x1b[2K%s\n", p.layers[i].barLine())
+	}
+	p.drawn = len(p.layers)
+	p.lastDraw = time.Now()
+}
+
+func (l *layerProgress) plainLine() string {
+	if l.id == "" {
+		return fmt.Sprintf("(%s) %s", l.image, l.status)
+	}
+	return fmt.Sprintf("(%s) %s: %s", l.image, l.id, l.status)
+}
+
+// EG. (mongo:latest) 8e5c1b329fe3: Downloading [=======>      ] 12.1MB/48.3MB ETA 9s
+func (l *layerProgress) barLine() string {
+	if l.id == "" {
+		return fmt.Sprintf("(%s) %s", l.image, l.status)
+	}
+	line := fmt.Sprintf("(%s) %s: %-11s", l.image, l.id, l.status)
+	if l.total <= 0 {
+		return line
+	}
+	current := l.current
+	if current > l.total {
+		current = l.total
+	}
+	filled := int(float64(progressBarWidth) * float64(current) / float64(l.total))
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	return fmt.Sprintf("%s [%s] %s/%s%s",
+		line, bar,
+		units.HumanSize(float64(current)), units.HumanSize(float64(l.total)),
+		l.eta(current),
+	)
+}
+
+// Estimates the remaining time from the average rate since the layer started
+func (l *layerProgress) eta(current int64) string {
+	elapsed := time.Since(l.started)
+	if current <= 0 || current >= l.total || elapsed < time.Second {
+		return ""
+	}
+	rate := float64(current) / elapsed.Seconds()
+	remaining := time.Duration(float64(l.total-current)/rate) * time.Second
+	return fmt.Sprintf(" ETA %s", units.HumanDuration(remaining))
+}
@@ -0,0 +1,88 @@
+package containers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestImagePullStreamWriter(t *testing.T) {
+	pulling := `{"status":"Pulling from library/mongo","id":"latest"}`
+	waiting := `{"status":"Waiting","id":"8e5c1b329fe3"}`
+	downloading := `{"status":"Downloading","id":"8e5c1b329fe3","progressDetail":{"current":10,"total":100}}`
+	downloaded := `{"status":"Download complete","id":"8e5c1b329fe3"}`
+	stream := pulling + "\r\n" + waiting + "\r\n" + downloading + "\r\n" + downloaded + "\r\n"
+	want := []string{
+		"(mongo:latest) Pulling from library/mongo",
+		"(mongo:latest) 8e5c1b329fe3: Waiting",
+		"(mongo:latest) 8e5c1b329fe3: Downloading",
+		"(mongo:latest) 8e5c1b329fe3: Download complete",
+	}
+
+	tests := []struct {
+		name   string
+		chunks []string
+	}{
+		{"single write", []string{stream}},
+		{"message per write", []string{pulling, waiting, downloading, downloaded}},
+		{"split messages", []string{pulling[:10], pulling[10:] + "\n" + waiting[:5], waiting[5:] + downloading, downloaded[:len(downloaded)-1], "}"}},
+		{"byte per write", strings.Split(stream, "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			p := &PullProgress{out: &out, index: make(map[string]*layerProgress)}
+			w := p.Writer("mongo:latest")
+			for _, chunk := range tt.chunks {
+				n, err := w.Write([]byte(chunk))
+				if err != nil {
+					t.Fatalf("Write(%q) error: %v", chunk, err)
+				}
+				if n != len(chunk) {
+					t.Fatalf("Write(%q) = %d, want %d", chunk, n, len(chunk))
+				}
+			}
+			got := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("output\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+			if len(p.layers) != 2 {
+				t.Errorf("got %d layers, want 2", len(p.layers))
+			}
+		})
+	}
+}
+
+func TestImagePullStreamWriterError(t *testing.T) {
+	var out bytes.Buffer
+	p := &PullProgress{out: &out, index: make(map[string]*layerProgress)}
+	w := p.Writer("mongo:nope")
+	_, err := w.Write([]byte(`{"error":"manifest unknown"}`))
+	if err == nil || !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("got error %v, want manifest unknown", err)
+	}
+}
+
+func TestImagePullStreamWriterLoad(t *testing.T) {
+	var out bytes.Buffer
+	p := &PullProgress{out: &out, index: make(map[string]*layerProgress)}
+	w := p.Writer("bundle")
+	if _, err := w.Write([]byte(`{"stream":"Loaded image: mongo:latest\n"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(out.String()), "(bundle) Loaded image: mongo:latest"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBarLine(t *testing.T) {
+	l := &layerProgress{image: "mongo", id: "8e5c1b329fe3", status: "Downloading", current: 50, total: 100}
+	got := l.barLine()
+	if !strings.Contains(got, "["+strings.Repeat("=", progressBarWidth/2)+">") {
+		t.Errorf("barLine() = %q, want a half filled bar", got)
+	}
+	l.current = 200
+	if got := l.barLine(); !strings.Contains(got, "["+strings.Repeat("=", progressBarWidth)+"]") {
+		t.Errorf("barLine() = %q, want a full bar when current exceeds total", got)
+	}
+}
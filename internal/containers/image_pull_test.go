@@ -0,0 +1,28 @@
+package containers
+
+import "testing"
+
+func TestParsePullPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    PullPolicy
+		wantErr bool
+	}{
+		{"always", PullAlways, false},
+		{"missing", PullMissing, false},
+		{"never", PullNever, false},
+		{"", "", true},
+		{"Always", "", true},
+		{"sometimes", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePullPolicy(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePullPolicy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePullPolicy(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
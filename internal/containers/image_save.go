@@ -0,0 +1,56 @@
+package containers
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/client"
+)
+
+type ImageSaver interface {
+	GetImages() []Image
+	Callback(ctx context.Context, err error) error
+	io.Writer
+}
+
+type imageSavePayload struct {
+	images   []Image
+	callback CallbackFn
+	writer   io.Writer
+}
+
+func (p imageSavePayload) GetImages() []Image { return p.images }
+func (p imageSavePayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+func (p imageSavePayload) Write(b []byte) (n int, err error) {
+	return p.writer.Write(b)
+}
+
+// Saves the images into a single tar bundle that is written to writer
+func NewImageSavePayload(images []Image, writer io.Writer, cb CallbackFn) ImageSaver {
+	if cb == nil {
+		return imageSavePayload{
+			images:   images,
+			callback: func(ctx context.Context, err error) error { return err },
+			writer:   writer,
+		}
+	}
+	return imageSavePayload{images: images, callback: cb, writer: writer}
+}
+
+func saveImages(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p ImageSaver) int {
+	images := p.GetImages()
+	names := make([]string, len(images))
+	for i := range images {
+		names[i] = images[i].Name
+	}
+	rc, err := client.ImageSave(ctx, names)
+	if err != nil {
+		return exit(wg, p.Callback(ctx, err))
+	}
+	defer rc.Close()
+	_, err = io.Copy(p, rc)
+	return exit(wg, p.Callback(ctx, err))
+}
@@ -0,0 +1,50 @@
+package containers
+
+// Labels set on every container, network and volume blah creates so they can be found without persist.db
+const (
+	// Name of the project EG. myproj
+	LabelProject = "io.blah.project"
+	// Name of the service EG. mongodb, not set on networks and helper containers
+	LabelService = "io.blah.service"
+	// Profile of the container or network, not set for the default profile
+	LabelProfile = "io.blah.profile"
+	// Version of blah that created the resource
+	LabelVersion = "io.blah.version"
+	// Image reference the container was created from before it was pinned by digest EG. mongo:6.0
+	LabelImage = "io.blah.image"
+	// Directory the image of the container is built from EG. /home/me/myproj/src
+	LabelBuildContext = "io.blah.build-context"
+)
+
+// Version of blah, set at build time with -ldflags "-X github.com/isolateminds/blah/internal/containers.Version=v1.0.0"
+var Version = "dev"
+
+// Returns the labels of a resource of a project, empty values are left out
+func Labels(project string, service string, profile string) map[string]string {
+	labels := map[string]string{
+		LabelProject: project,
+		LabelVersion: Version,
+	}
+	if service != "" {
+		labels[LabelService] = service
+	}
+	if profile != "" {
+		labels[LabelProfile] = profile
+	}
+	return labels
+}
+
+// Returns the labels of the container, containers persisted before projects were recorded have none
+func (c Container) CreateLabels() map[string]string {
+	if c.Project == "" {
+		return nil
+	}
+	labels := Labels(c.Project, c.Service, c.Profile)
+	if c.Image != "" {
+		labels[LabelImage] = c.Image
+	}
+	if c.BuildContext != "" {
+		labels[LabelBuildContext] = c.BuildContext
+	}
+	return labels
+}
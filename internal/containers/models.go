@@ -2,8 +2,14 @@ package containers
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/go-connections/nat"
 	"gorm.io/gorm"
 )
@@ -37,17 +43,110 @@ type ContainerEnv struct {
 	Value    string `json:"value"`
 }
 
+// For persisting the command arguments in order EG. redis-server --appendonly yes
+type ContainerCmdArg struct {
+	gorm.Model
+	CmdRefer uint
+	Arg      string `json:"arg"`
+}
+
+// For persisting additional /etc/hosts entries EG. host.docker.internal:host-gateway
+type ContainerExtraHost struct {
+	gorm.Model
+	ExtraHostRefer uint
+	Host           string `json:"host"`
+}
+
+// For persisting the memory and CPU limits of a container, zero means unlimited
+type ContainerResources struct {
+	gorm.Model
+	ResourcesRefer uint
+	// Memory limit in bytes
+	Memory int64 `json:"memory"`
+	// CPU quota in units of 10^-9 CPUs EG. 1500000000 for 1.5 CPUs
+	NanoCPUs int64 `json:"nanoCPUs"`
+}
+
+// A reverse proxy route that is rendered into the project nginx.conf
+type ProxyRoute struct {
+	gorm.Model
+	// A path EG. /api or a host EG. api.myproj.localhost
+	Pattern  string `json:"pattern" gorm:"uniqueIndex"`
+	Upstream string `json:"upstream"`
+}
+
 //Configuration struct for create container
 type Container struct {
 	gorm.Model
 	ContainerID  string                 `json:"containerID"`
 	Name         string                 `json:"name"`
+	Project      string                 `json:"project"`
+	Service      string                 `json:"service"`
+	Profile      string                 `gorm:"index" json:"profile"`
 	Image        string                 `json:"image"`
+	ImageDigest  string                 `json:"imageDigest"`
 	Hostname     string                 `json:"hostname"`
+	Network      string                 `json:"network"`
+	WorkingDir   string                 `json:"workingDir"`
+	BuildContext string                 `json:"buildContext"`
+	AutoRemove   bool                   `json:"autoRemove"`
+	User         string                 `json:"user"`
+	Restart      RestartPolicy          `json:"restart"`
+	Entrypoint   []string               `gorm:"-" json:"-"` // only set for helper containers, not persisted
 	Mounts       []ContainerMount       `gorm:"foreignKey:MountRefer;       constraint:OnDelete:CASCADE;" json:"mounts"`
 	ExposedPorts []ContainerExposedPort `gorm:"foreignKey:ExposedPortRefer; constraint:OnDelete:CASCADE;" json:"exposedPorts"`
 	PortBindings []ContainerPortBinding `gorm:"foreignKey:PortBindingRefer; constraint:OnDelete:CASCADE;" json:"portBindings"`
 	Env          []ContainerEnv         `gorm:"foreignKey:EnvRefer;         constraint:OnDelete:CASCADE;" json:"env"`
+	Cmd          []ContainerCmdArg      `gorm:"foreignKey:CmdRefer;         constraint:OnDelete:CASCADE;" json:"cmd"`
+	Resources    ContainerResources     `gorm:"foreignKey:ResourcesRefer;   constraint:OnDelete:CASCADE;" json:"resources"`
+	ExtraHosts   []ContainerExtraHost   `gorm:"foreignKey:ExtraHostRefer;   constraint:OnDelete:CASCADE;" json:"extraHosts"`
+}
+
+// Returns the image reference used at create, pinned by digest once it was resolved EG. mongo@sha256:4200c30...
+func (c Container) ImageRef() string {
+	if c.ImageDigest == "" {
+		return c.Image
+	}
+	named, err := reference.ParseNormalizedNamed(c.Image)
+	if err != nil {
+		return c.Image
+	}
+	return fmt.Sprintf("%s@%s", reference.FamiliarName(named), c.ImageDigest)
+}
+
+// Maps a path inside the container to the host path of the bind mount it lives in
+func (c Container) HostPath(target string) (string, bool) {
+	for i := range c.Mounts {
+		if c.Mounts[i].Type != mount.TypeBind {
+			continue
+		}
+		mountTarget := c.Mounts[i].Tagret
+		if target == mountTarget {
+			return c.Mounts[i].Source, true
+		}
+		if strings.HasPrefix(target, mountTarget+"/") {
+			return filepath.Join(c.Mounts[i].Source, strings.TrimPrefix(target, mountTarget+"/")), true
+		}
+	}
+	return "", false
+}
+
+// Returns nil when the container is not attached to a network, the service name is used
+// as an alias so other containers of the project reach it EG. mongodb:27017. Helper containers
+// without a service get no alias
+func (c Container) CreateNetworkingConfig() *network.NetworkingConfig {
+	if c.Network == "" {
+		return &network.NetworkingConfig{}
+	}
+	var aliases []string
+	if c.Service != "" {
+		aliases = []string{c.Service}
+	}
+	return &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			c.Network: {Aliases: aliases},
+		},
+	}
 }
 
 //Had to make different methods here because gorm not being able to accept some types the docker sdk uses
@@ -60,6 +159,35 @@ func (c Container) CreateENVKeyPair() []string {
 	return keypair
 }
 
+// Returns nil when no command was set so the image default command is used
+func (c Container) CreateCmd() strslice.StrSlice {
+	if len(c.Cmd) == 0 {
+		return nil
+	}
+	cmd := make(strslice.StrSlice, len(c.Cmd))
+	for i := range c.Cmd {
+		cmd[i] = c.Cmd[i].Arg
+	}
+	return cmd
+}
+
+// Returns the host:ip entries that are added to /etc/hosts of the container
+func (c Container) CreateExtraHosts() []string {
+	var hosts []string
+	for i := range c.ExtraHosts {
+		hosts = append(hosts, c.ExtraHosts[i].Host)
+	}
+	return hosts
+}
+
+// Returns the resources of the host config, a zero limit leaves the container unlimited
+func (c Container) CreateResources() container.Resources {
+	return container.Resources{
+		Memory:   c.Resources.Memory,
+		NanoCPUs: c.Resources.NanoCPUs,
+	}
+}
+
 func (c Container) CreateNatExposedPortSet() nat.PortSet {
 	pSet := make(nat.PortSet)
 	for i := range c.ExposedPorts {
@@ -79,16 +207,38 @@ func (c Container) CreatePortBindings() nat.PortMap {
 	}
 	return pMap
 }
+
+// Mounts without a stored type are bind mounts, tmpfs mounts have no source and
+// the source of a volume mount is the name of the volume
 func (c Container) CreateMounts() []mount.Mount {
 	var mounts []mount.Mount
 	for i := range c.Mounts {
-
-		mounts = append(mounts, mount.Mount{
-			Type:   mount.TypeBind,
+		m := mount.Mount{
+			Type:   c.Mounts[i].Type,
 			Source: c.Mounts[i].Source,
 			Target: c.Mounts[i].Tagret,
-		})
+		}
+		switch m.Type {
+		case "":
+			m.Type = mount.TypeBind
+		case mount.TypeTmpfs:
+			m.Source = ""
+		}
+		mounts = append(mounts, m)
 	}
 
 	return mounts
 }
+
+// Returns true if the route matches a host instead of a path
+func (r ProxyRoute) IsHost() bool {
+	return !strings.HasPrefix(r.Pattern, "/")
+}
+
+// Location of a path route, a trailing slash is added so /api does not match /apiv2
+func (r ProxyRoute) Location() string {
+	if strings.HasSuffix(r.Pattern, "/") {
+		return r.Pattern
+	}
+	return r.Pattern + "/"
+}
@@ -0,0 +1,53 @@
+package containers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+type NetworkCreator interface {
+	GetNetworkName() string
+	GetLabels() map[string]string
+	Callback(ctx context.Context, err error) error
+}
+
+type networkCreatePayload struct {
+	name     string
+	labels   map[string]string
+	callback CallbackFn
+}
+
+func (p networkCreatePayload) GetNetworkName() string       { return p.name }
+func (p networkCreatePayload) GetLabels() map[string]string { return p.labels }
+func (p networkCreatePayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Creates a bridge network for the containers of a project, if the network
+// already exists the callback receives an ErrNetworkExists error
+func NewNetworkCreatePayload(name string, labels map[string]string, cb CallbackFn) NetworkCreator {
+	if cb == nil {
+		return networkCreatePayload{
+			name:     name,
+			labels:   labels,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return networkCreatePayload{name: name, labels: labels, callback: cb}
+}
+
+func createNetwork(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p NetworkCreator) int {
+	_, err := client.NetworkCreate(ctx, p.GetNetworkName(), types.NetworkCreate{
+		CheckDuplicate: true,
+		Driver:         "bridge",
+		Labels:         p.GetLabels(),
+	})
+	if errdefs.IsConflict(err) {
+		return exit(wg, p.Callback(ctx, networkExistsError(err)))
+	}
+	return exit(wg, p.Callback(ctx, err))
+}
@@ -0,0 +1,63 @@
+package containers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/docker/go-units"
+)
+
+// Docker refuses memory limits below 6MB
+const minMemory = 6 * units.MiB
+
+// Returns the resources for a memory limit EG. 512m and a number of CPUs EG. 1.5
+func NewContainerResources(memory string, cpus string) (ContainerResources, error) {
+	var (
+		r   ContainerResources
+		err error
+	)
+	if r.Memory, err = ParseMemory(memory); err != nil {
+		return r, err
+	}
+	r.NanoCPUs, err = ParseCPUs(cpus)
+	return r, err
+}
+
+// Parses a memory limit EG. 512m or 1g, 0 removes the limit
+func ParseMemory(memory string) (int64, error) {
+	bytes, err := units.RAMInBytes(memory)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid memory %q should be a size EG. 512m or 1g", memory)
+	}
+	if bytes != 0 && bytes < minMemory {
+		return 0, fmt.Errorf("Memory %s is below the minimum of 6m", memory)
+	}
+	return bytes, nil
+}
+
+// Parses a number of CPUs EG. 1.5 into nano CPUs, 0 removes the limit
+func ParseCPUs(cpus string) (int64, error) {
+	n, err := strconv.ParseFloat(cpus, 64)
+	//the conversion of NaN, Inf and values beyond int64 to nano CPUs is undefined
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) || n < 0 || n*1e9 >= math.MaxInt64 {
+		return 0, fmt.Errorf("Invalid cpus %q should be a number EG. 0.5 or 2", cpus)
+	}
+	nano := int64(n * 1e9)
+	if n > 0 && nano == 0 {
+		return 0, fmt.Errorf("Cpus %s is below the minimum of 0.000000001", cpus)
+	}
+	return nano, nil
+}
+
+// Formats the limits for output EG. memory=512MiB cpus=1.5
+func (r ContainerResources) String() string {
+	memory, cpus := "unlimited", "unlimited"
+	if r.Memory != 0 {
+		memory = units.BytesSize(float64(r.Memory))
+	}
+	if r.NanoCPUs != 0 {
+		cpus = strconv.FormatFloat(float64(r.NanoCPUs)/1e9, 'f', -1, 64)
+	}
+	return fmt.Sprintf("memory=%s cpus=%s", memory, cpus)
+}
@@ -0,0 +1,87 @@
+package containers
+
+import (
+	"testing"
+
+	"github.com/docker/go-units"
+)
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"512m", 512 * units.MiB, false},
+		{"1g", units.GiB, false},
+		{"1.5g", 3 * units.GiB / 2, false},
+		{"256MiB", 256 * units.MiB, false},
+		{"6m", 6 * units.MiB, false},
+		{"0", 0, false},
+		{"5m", 0, true},
+		{"6291455", 0, true},
+		{"1k", 0, true},
+		{"", 0, true},
+		{"lots", 0, true},
+		{"-1g", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMemory(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMemory(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMemory(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseCPUs(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"1", 1e9, false},
+		{"0.5", 5e8, false},
+		{"1.5", 15e8, false},
+		{"0", 0, false},
+		{"0.000000001", 1, false},
+		{"0.0000000001", 0, true},
+		{"-1", 0, true},
+		{"NaN", 0, true},
+		{"nan", 0, true},
+		{"Inf", 0, true},
+		{"+Inf", 0, true},
+		{"-Inf", 0, true},
+		{"1e10", 0, true},
+		{"", 0, true},
+		{"two", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCPUs(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCPUs(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCPUs(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestContainerResourcesString(t *testing.T) {
+	tests := []struct {
+		r    ContainerResources
+		want string
+	}{
+		{ContainerResources{}, "memory=unlimited cpus=unlimited"},
+		{ContainerResources{Memory: 512 * units.MiB, NanoCPUs: 15e8}, "memory=512MiB cpus=1.5"},
+	}
+	for _, tt := range tests {
+		if got := tt.r.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package containers
+
+import (
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Decides whether docker restarts a container when it exits or when the daemon restarts
+type RestartPolicy string
+
+const (
+	// Never restarts the container, used when no policy was stored
+	RestartNo RestartPolicy = "no"
+	// Restarts the container when it exits with a non zero code
+	RestartOnFailure RestartPolicy = "on-failure"
+	// Restarts the container unless it was stopped, it is started again when the daemon restarts
+	RestartUnlessStopped RestartPolicy = "unless-stopped"
+	// Always restarts the container, even if it was stopped before the daemon restarted
+	RestartAlways RestartPolicy = "always"
+)
+
+// Number of times docker restarts a failing container with the on-failure policy before giving up,
+// so a broken configuration does not end up in an endless restart loop
+const maxRestartRetries = 5
+
+// Parses a restart policy setting
+func ParseRestartPolicy(policy string) (RestartPolicy, error) {
+	switch p := RestartPolicy(policy); p {
+	case RestartNo, RestartOnFailure, RestartUnlessStopped, RestartAlways:
+		return p, nil
+	}
+	return "", fmt.Errorf("Invalid restart policy %q should be one of no, on-failure, unless-stopped or always", policy)
+}
+
+// Returns the restart policy of the host config, containers that docker removes on stop can not be restarted
+func (c Container) CreateRestartPolicy() container.RestartPolicy {
+	if c.Restart == "" || c.AutoRemove {
+		return container.RestartPolicy{Name: string(RestartNo)}
+	}
+	if c.Restart == RestartOnFailure {
+		return container.RestartPolicy{Name: string(c.Restart), MaximumRetryCount: maxRestartRetries}
+	}
+	return container.RestartPolicy{Name: string(c.Restart)}
+}
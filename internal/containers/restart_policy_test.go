@@ -0,0 +1,38 @@
+package containers
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestCreateRestartPolicy(t *testing.T) {
+	tests := []struct {
+		c    Container
+		want container.RestartPolicy
+	}{
+		{Container{}, container.RestartPolicy{Name: "no"}},
+		{Container{Restart: RestartOnFailure}, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: maxRestartRetries}},
+		{Container{Restart: RestartUnlessStopped}, container.RestartPolicy{Name: "unless-stopped"}},
+		{Container{Restart: RestartAlways}, container.RestartPolicy{Name: "always"}},
+		{Container{Restart: RestartAlways, AutoRemove: true}, container.RestartPolicy{Name: "no"}},
+	}
+	for _, tt := range tests {
+		if got := tt.c.CreateRestartPolicy(); got != tt.want {
+			t.Errorf("CreateRestartPolicy() for %q = %+v, want %+v", tt.c.Restart, got, tt.want)
+		}
+	}
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	for _, policy := range []string{"no", "on-failure", "unless-stopped", "always"} {
+		if got, err := ParseRestartPolicy(policy); err != nil || string(got) != policy {
+			t.Errorf("ParseRestartPolicy(%q) = %q, %v", policy, got, err)
+		}
+	}
+	for _, policy := range []string{"", "never", "on-failure:3"} {
+		if _, err := ParseRestartPolicy(policy); err == nil {
+			t.Errorf("ParseRestartPolicy(%q) accepted an invalid policy", policy)
+		}
+	}
+}
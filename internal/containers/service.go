@@ -0,0 +1,125 @@
+package containers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/isolateminds/blah/internal/envfile"
+	"github.com/isolateminds/blah/internal/utils"
+)
+
+// Decides where the data of a service is kept
+type Storage string
+
+const (
+	// Bind mounts a directory in the database directory of the project EG. database/mongodb
+	StorageBind Storage = "bind"
+	// Mounts a named volume managed by docker EG. myproj_mongodb_data
+	StorageVolume Storage = "volume"
+)
+
+// Parses a storage flag value
+func ParseStorage(storage string) (Storage, error) {
+	switch s := Storage(storage); s {
+	case StorageBind, StorageVolume:
+		return s, nil
+	}
+	return "", fmt.Errorf("Invalid storage %q should be one of bind or volume", storage)
+}
+
+// Returns the name of the volume that keeps the data of a container EG. myproj_mongodb_data
+func DataVolume(containerName string) string {
+	return containerName + "_data"
+}
+
+// Options passed to the InitialSetup function of every service package
+type ServiceOptions struct {
+	// Image reference of the service EG. mongo:6.0 the package DefaultImgTag is used when empty
+	Image string
+	// Serve HTTPS with a certificate signed by the development CA, only used by nginx
+	TLS bool
+	// Project network the container joins EG. myproj_default
+	Network string
+	// Containers already part of the project, used by services that connect to them
+	Peers []*Container
+	// Where the data of databases is kept, bind mounts are used when empty
+	Storage Storage
+	// Host user the databases run as EG. 1000:1000 so their bind mounted data stays owned by the developer
+	User string
+	// Host ports used by other projects on the machine and the containers of the project
+	UsedPorts map[string]bool
+}
+
+// Returns the image of the options or the fallback if no image was chosen
+func (o ServiceOptions) ImageOrDefault(fallback string) string {
+	if o.Image == "" {
+		return fallback
+	}
+	return o.Image
+}
+
+// Returns preferred if it is not used yet, otherwise the next free port above it EG. 3187 when 3186 is used.
+// The returned port is marked as used so the next service of the project does not pick it as well
+func (o ServiceOptions) HostPort(preferred string) string {
+	n, err := strconv.Atoi(preferred)
+	if o.UsedPorts == nil || err != nil {
+		return preferred
+	}
+	port := preferred
+	for o.UsedPorts[port] {
+		n++
+		port = strconv.Itoa(n)
+	}
+	o.UsedPorts[port] = true
+	return port
+}
+
+// Returns the user a container with the data mount runs as. Volumes are owned by the user of the image
+// so only containers with bind mounted data run as the host user
+func (o ServiceOptions) UserFor(dataMount ContainerMount) string {
+	if dataMount.Type != mount.TypeBind {
+		return ""
+	}
+	return o.User
+}
+
+// Returns the mount that keeps the data of a container at target. With StorageVolume the named
+// volume of the container is mounted, otherwise dir is created and bind mounted
+func (o ServiceOptions) DataMount(containerName string, dir string, target string) (ContainerMount, error) {
+	if o.Storage == StorageVolume {
+		return ContainerMount{Type: mount.TypeVolume, Source: DataVolume(containerName), Tagret: target}, nil
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return ContainerMount{}, err
+	}
+	source, err := filepath.Abs(dir)
+	if err != nil {
+		return ContainerMount{}, err
+	}
+	return ContainerMount{Type: mount.TypeBind, Source: source, Tagret: target}, nil
+}
+
+// Wraps the creation callback of a service so the env of the container is merged into the env file once
+// the container was created, a failed creation leaves no keys behind. Old keys in renames are migrated first
+func MergeEnvOnCreate(path string, c *Container, renames map[string]string, cb CallbackFn) CallbackFn {
+	return func(ctx context.Context, err error) error {
+		if err == nil {
+			err = utils.UntilError(
+				func() error {
+					return envfile.Rename(path, renames)
+				},
+				func() error {
+					return envfile.Merge(path, c.CreateENVKeyPair()...)
+				},
+			)
+		}
+		if cb == nil {
+			return err
+		}
+		return cb(ctx, err)
+	}
+}
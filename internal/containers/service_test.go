@@ -0,0 +1,37 @@
+package containers
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeEnvOnCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := ioutil.WriteFile(path, []byte("OLD_USER=admin\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := &Container{Env: []ContainerEnv{{Key: "NEW_USER", Value: "admin"}, {Key: "NEW_PORT", Value: "3186"}}}
+	var called error
+	cb := MergeEnvOnCreate(path, c, map[string]string{"OLD_USER": "NEW_USER"}, func(ctx context.Context, err error) error {
+		called = err
+		return err
+	})
+
+	failed := errors.New("port is already allocated")
+	if err := cb(context.Background(), failed); err != failed || called != failed {
+		t.Fatalf("cb returned %v and was called with %v, want %v", err, called, failed)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "OLD_USER=admin\n" {
+		t.Errorf("a failed creation changed the env file to %q", b)
+	}
+
+	if err := cb(context.Background(), nil); err != nil || called != nil {
+		t.Fatalf("cb returned %v and was called with %v", err, called)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "NEW_USER=admin\nNEW_PORT=3186\n" {
+		t.Errorf("got env file %q", b)
+	}
+}
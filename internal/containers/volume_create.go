@@ -0,0 +1,48 @@
+package containers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+type VolumeCreator interface {
+	GetVolumeName() string
+	GetLabels() map[string]string
+	Callback(ctx context.Context, err error) error
+}
+
+type volumeCreatePayload struct {
+	name     string
+	labels   map[string]string
+	callback CallbackFn
+}
+
+func (p volumeCreatePayload) GetVolumeName() string        { return p.name }
+func (p volumeCreatePayload) GetLabels() map[string]string { return p.labels }
+func (p volumeCreatePayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Creates a named volume with the local driver, an existing volume with the same name is reused
+func NewVolumeCreatePayload(name string, labels map[string]string, cb CallbackFn) VolumeCreator {
+	if cb == nil {
+		return volumeCreatePayload{
+			name:     name,
+			labels:   labels,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return volumeCreatePayload{name: name, labels: labels, callback: cb}
+}
+
+func createVolume(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p VolumeCreator) int {
+	_, err := client.VolumeCreate(ctx, volume.VolumeCreateBody{
+		Name:   p.GetVolumeName(),
+		Driver: "local",
+		Labels: p.GetLabels(),
+	})
+	return exit(wg, p.Callback(ctx, err))
+}
@@ -0,0 +1,62 @@
+package containers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+type volumeKey int
+
+var (
+	volumeInspectKey volumeKey
+	volumeListKey    volumeKey = 1
+)
+
+type VolumeInspector interface {
+	GetInspectVolumeName() string
+	Callback(ctx context.Context, err error) error
+}
+
+type volumeInspectPayload struct {
+	name     string
+	callback CallbackFn
+}
+
+func (p volumeInspectPayload) GetInspectVolumeName() string { return p.name }
+func (p volumeInspectPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Inspects a named volume, the volume is passed to the callback via context see FromVolumeInspectContext.
+// If the volume does not exist the callback receives an ErrVolumeNotFound error
+func NewVolumeInspectPayload(name string, cb CallbackFn) VolumeInspector {
+	if cb == nil {
+		return volumeInspectPayload{
+			name:     name,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return volumeInspectPayload{name: name, callback: cb}
+}
+
+// Retrieves the inspected volume from its context
+func FromVolumeInspectContext(ctx context.Context) (*types.Volume, bool) {
+	v, ok := ctx.Value(volumeInspectKey).(*types.Volume)
+	return v, ok
+}
+
+func inspectVolume(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p VolumeInspector) int {
+	v, err := client.VolumeInspect(ctx, p.GetInspectVolumeName())
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return exit(wg, p.Callback(ctx, volumeNotFoundError(err)))
+		}
+		return exit(wg, p.Callback(ctx, err))
+	}
+	ctx = context.WithValue(ctx, volumeInspectKey, &v)
+	return exit(wg, p.Callback(ctx, nil))
+}
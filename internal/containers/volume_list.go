@@ -0,0 +1,60 @@
+package containers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+type VolumeLister interface {
+	GetVolumeListLabels() map[string]string
+	Callback(ctx context.Context, err error) error
+}
+
+type volumeListPayload struct {
+	labels   map[string]string
+	callback CallbackFn
+}
+
+func (p volumeListPayload) GetVolumeListLabels() map[string]string { return p.labels }
+func (p volumeListPayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Lists the volumes that have every label EG. io.blah.project=myproj, an empty value only requires
+// the label to be present. The volumes are passed to the callback via context see FromVolumeListContext
+func NewVolumeListPayload(labels map[string]string, cb CallbackFn) VolumeLister {
+	if cb == nil {
+		return volumeListPayload{
+			labels:   labels,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return volumeListPayload{labels: labels, callback: cb}
+}
+
+// Retrieves the listed volumes from its context
+func FromVolumeListContext(ctx context.Context) ([]*types.Volume, bool) {
+	list, ok := ctx.Value(volumeListKey).([]*types.Volume)
+	return list, ok
+}
+
+func listVolumes(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p VolumeLister) int {
+	args := filters.NewArgs()
+	for key, value := range p.GetVolumeListLabels() {
+		if value == "" {
+			args.Add("label", key)
+			continue
+		}
+		args.Add("label", key+"="+value)
+	}
+	body, err := client.VolumeList(ctx, args)
+	if err != nil {
+		return exit(wg, p.Callback(ctx, err))
+	}
+	ctx = context.WithValue(ctx, volumeListKey, body.Volumes)
+	return exit(wg, p.Callback(ctx, nil))
+}
@@ -0,0 +1,44 @@
+package containers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+type VolumeRemover interface {
+	GetRMVolumeName() string
+	Callback(ctx context.Context, err error) error
+}
+
+type volumeRemovePayload struct {
+	name     string
+	callback CallbackFn
+}
+
+func (p volumeRemovePayload) GetRMVolumeName() string { return p.name }
+func (p volumeRemovePayload) Callback(ctx context.Context, err error) error {
+	return p.callback(ctx, err)
+}
+
+// Removes a named volume and its data, the containers using it have to be removed first.
+// If the volume does not exist the callback receives an ErrVolumeNotFound error
+func NewVolumeRemovePayload(name string, cb CallbackFn) VolumeRemover {
+	if cb == nil {
+		return volumeRemovePayload{
+			name:     name,
+			callback: func(ctx context.Context, err error) error { return err },
+		}
+	}
+	return volumeRemovePayload{name: name, callback: cb}
+}
+
+func removeVolume(ctx context.Context, client *client.Client, wg *sync.WaitGroup, p VolumeRemover) int {
+	err := client.VolumeRemove(ctx, p.GetRMVolumeName(), false)
+	if errdefs.IsNotFound(err) {
+		return exit(wg, p.Callback(ctx, volumeNotFoundError(err)))
+	}
+	return exit(wg, p.Callback(ctx, err))
+}
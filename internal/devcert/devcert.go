@@ -0,0 +1,187 @@
+package devcert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	caCertFile = "ca.pem"
+	caKeyFile  = "ca-key.pem"
+
+	// Names of the leaf certificate files written into a project
+	CertFile = "cert.pem"
+	KeyFile  = "key.pem"
+
+	caValidity = 10 * 365 * 24 * time.Hour
+	// browsers reject leaf certificates that are valid for longer than 825 days
+	leafValidity = 825 * 24 * time.Hour
+)
+
+// Returns the directory of the per user development CA EG. ~/.config/blah/ca
+func CADir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "blah", "ca"), nil
+}
+
+// Returns the path of the CA certificate that has to be trusted by browsers
+func CACertPath() (string, error) {
+	dir, err := CADir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, caCertFile), nil
+}
+
+// Loads the development CA or creates it the first time it is needed
+func LoadOrCreateCA() (*x509.Certificate, crypto.Signer, error) {
+	dir, err := CADir()
+	if err != nil {
+		return nil, nil, err
+	}
+	certPath := filepath.Join(dir, caCertFile)
+	keyPath := filepath.Join(dir, caKeyFile)
+	if _, err := os.Stat(certPath); err == nil {
+		return loadCA(certPath, keyPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "developer"
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization:       []string{"blah development CA"},
+			OrganizationalUnit: []string{user},
+			CommonName:         fmt.Sprintf("blah development CA %s", user),
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := writePEM(certPath, "CERTIFICATE", der, 0644); err != nil {
+		return nil, nil, err
+	}
+	if err := writeKey(keyPath, key); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// Issues a leaf certificate for <project>.localhost, its subdomains and localhost
+// signed by the CA and writes cert.pem and key.pem into dir
+func IssueProjectCert(ca *x509.Certificate, caKey crypto.Signer, projectName string, dir string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return err
+	}
+	host := fmt.Sprintf("%s.localhost", projectName)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			Organization: []string{"blah development certificate"},
+			CommonName:   host,
+		},
+		DNSNames:    []string{host, "*." + host, "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(leafValidity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	if err := writePEM(filepath.Join(dir, CertFile), "CERTIFICATE", der, 0644); err != nil {
+		return err
+	}
+	return writeKey(filepath.Join(dir, KeyFile), key)
+}
+
+func loadCA(certPath string, keyPath string) (*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, fmt.Errorf("Could not decode the development CA in %s", filepath.Dir(certPath))
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("The development CA key in %s can not sign certificates", keyPath)
+	}
+	return cert, signer, nil
+}
+
+func writeKey(path string, key *ecdsa.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	return writePEM(path, "PRIVATE KEY", der, 0600)
+}
+
+func writePEM(path string, blockType string, der []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm)
+}
+
+func randomSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
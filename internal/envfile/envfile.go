@@ -0,0 +1,248 @@
+package envfile
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/isolateminds/blah/internal/utils"
+)
+
+// Values made of these characters are written without quotes, anything else EG. spaces, # or $ is quoted
+var bareValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,\-?&=~!*]*$`)
+
+// A .env file that keeps the comments, blank lines and order of the variables it was parsed from.
+// Lines that are not changed are written back exactly as they were read
+type File struct {
+	lines []line
+}
+
+type line struct {
+	raw   string
+	key   string
+	value string
+}
+
+// Parses the KEY=value lines of a .env file, export prefixes, quoted values and comments are supported
+func Parse(data []byte) *File {
+	f := &File{}
+	text := strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return f
+	}
+	for _, raw := range strings.Split(text, "\n") {
+		l := line{raw: raw}
+		trimmed := strings.TrimSpace(raw)
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			trimmed = strings.TrimPrefix(trimmed, "export ")
+			if key, value, ok := strings.Cut(trimmed, "="); ok && strings.TrimSpace(key) != "" {
+				l.key = strings.TrimSpace(key)
+				l.value = unquote(strings.TrimSpace(value))
+			}
+		}
+		f.lines = append(f.lines, l)
+	}
+	return f
+}
+
+// Reads and parses a .env file, a file that does not exist is empty
+func Read(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &File{}, nil
+		}
+		return nil, err
+	}
+	return Parse(data), nil
+}
+
+// Reads the file, sets the KEY=value pairs and writes it atomically. Existing keys are updated in place
+// and new keys are appended so running a setup again never duplicates a variable
+func Merge(path string, pairs ...string) error {
+	f, err := Read(path)
+	if err != nil {
+		return err
+	}
+	for i := range pairs {
+		key, value, ok := strings.Cut(pairs[i], "=")
+		if !ok {
+			return fmt.Errorf("%q is not a KEY=value pair", pairs[i])
+		}
+		f.Set(key, value)
+	}
+	return f.Write(path)
+}
+
+// Reads the file, deletes the keys and writes it atomically
+func Remove(path string, keys ...string) error {
+	if !utils.FileExists(path) {
+		return nil
+	}
+	f, err := Read(path)
+	if err != nil {
+		return err
+	}
+	f.Delete(keys...)
+	return f.Write(path)
+}
+
+// Reads the file, renames the keys that are in renames and writes it atomically. A key whose new name is
+// already in the file is deleted so migrating old keys never duplicates a variable
+func Rename(path string, renames map[string]string) error {
+	if !utils.FileExists(path) {
+		return nil
+	}
+	f, err := Read(path)
+	if err != nil {
+		return err
+	}
+	f.Rename(renames)
+	return f.Write(path)
+}
+
+// Returns the value of a key
+func (f *File) Get(key string) (string, bool) {
+	for i := range f.lines {
+		if f.lines[i].key == key {
+			return f.lines[i].value, true
+		}
+	}
+	return "", false
+}
+
+// Updates the value of a key or appends it if the file does not have it yet
+func (f *File) Set(key string, value string) {
+	raw := fmt.Sprintf("%s=%s", key, Quote(value))
+	for i := range f.lines {
+		if f.lines[i].key == key {
+			if f.lines[i].value != value {
+				f.lines[i] = line{raw: raw, key: key, value: value}
+			}
+			return
+		}
+	}
+	f.lines = append(f.lines, line{raw: raw, key: key, value: value})
+}
+
+// Deletes the lines of the keys
+func (f *File) Delete(keys ...string) {
+	remove := make(map[string]bool)
+	for i := range keys {
+		remove[keys[i]] = true
+	}
+	kept := f.lines[:0]
+	for i := range f.lines {
+		if f.lines[i].key == "" || !remove[f.lines[i].key] {
+			kept = append(kept, f.lines[i])
+		}
+	}
+	f.lines = kept
+}
+
+// Renames the keys that are in renames in place and keeps their values, a key whose new name
+// is already in the file is deleted
+func (f *File) Rename(renames map[string]string) {
+	var stale []string
+	for i := range f.lines {
+		newKey, ok := renames[f.lines[i].key]
+		if !ok {
+			continue
+		}
+		if _, exists := f.Get(newKey); exists {
+			stale = append(stale, f.lines[i].key)
+			continue
+		}
+		value := f.lines[i].value
+		f.lines[i] = line{raw: fmt.Sprintf("%s=%s", newKey, Quote(value)), key: newKey, value: value}
+	}
+	f.Delete(stale...)
+}
+
+// Returns the keys in the order they appear in the file
+func (f *File) Keys() []string {
+	var keys []string
+	for i := range f.lines {
+		if f.lines[i].key != "" {
+			keys = append(keys, f.lines[i].key)
+		}
+	}
+	return keys
+}
+
+// Returns the variables as a map, a key that appears twice has its last value like godotenv
+func (f *File) Map() map[string]string {
+	vars := make(map[string]string)
+	for i := range f.lines {
+		if f.lines[i].key != "" {
+			vars[f.lines[i].key] = f.lines[i].value
+		}
+	}
+	return vars
+}
+
+// Returns the content of the file
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	for i := range f.lines {
+		buf.WriteString(f.lines[i].raw)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// Writes the file atomically, the .env holds secrets so it is only readable by its owner
+func (f *File) Write(path string) error {
+	return utils.WriteFileAtomic(path, f.Bytes(), 0600)
+}
+
+// Quotes a value so godotenv reads it back unchanged. Single quotes are preferred because
+// godotenv expands $VARIABLES in unquoted and double quoted values
+func Quote(value string) string {
+	if bareValue.MatchString(value) {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`).Replace(value)
+	return `"` + escaped + `"`
+}
+
+// Removes the quotes of a value or the inline comment of an unquoted value
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '\'' {
+		if end := strings.IndexByte(value[1:], '\''); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if len(value) >= 2 && value[0] == '"' {
+		var b strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; {
+			case c == '"':
+				return b.String()
+			case c == '\\' && i+1 < len(value):
+				i++
+				switch value[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 'r':
+					b.WriteByte('\r')
+				default:
+					b.WriteByte(value[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+	}
+	//godotenv 1.4 cuts unquoted values at the first # even without a space before it
+	if i := strings.IndexByte(value, '#'); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
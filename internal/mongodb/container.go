@@ -5,11 +5,13 @@ import (
 	_ "embed"
 	"net/url"
 	"os"
+	"path"
 
 	"context"
 	"fmt"
 
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
 	"github.com/isolateminds/blah/internal/color"
 	"github.com/isolateminds/blah/internal/containers"
 	"github.com/isolateminds/blah/internal/utils"
@@ -23,8 +25,23 @@ var (
 	defaultContainerName = "mongo"
 
 	DefaultImgTag = "mongo:latest"
+
+	// Development limits so several projects fit on a laptop, WiredTiger sizes its cache to the memory limit
+	defaultResources = containers.ContainerResources{Memory: units.GiB, NanoCPUs: 1e9}
 )
 
+// Name of the service used for flags and container names EG. myproj_mongodb
+const ServiceName = "mongodb"
+
+// Keys written to the .env by earlier versions, they are renamed so every key of the service starts with MONGODB_
+var legacyEnvKeys = map[string]string{
+	"MONGO_INITDB_DATABASE":      "MONGODB_DATABASE",
+	"MONGO_INITDB_USERNAME":      "MONGODB_USER",
+	"MONGO_INITDB_PASSWORD":      "MONGODB_PASSWORD",
+	"MONGO_INITDB_ROOT_USERNAME": "MONGODB_ROOT_USER",
+	"MONGO_INITDB_ROOT_PASSWORD": "MONGODB_ROOT_PASSWORD",
+}
+
 //Container creation object spefically for mongodb image
 
 type startContainerPayload struct {
@@ -40,7 +57,7 @@ func (p startContainerPayload) GetStartOptions() containers.ContainerStartOption
 }
 
 // Prompts user for mongodb authentication details and makes all necessary mount points
-func InitialSetup(projectName string, cb containers.CallbackFn) (containers.ContainerCreator, error) {
+func InitialSetup(projectName string, opt containers.ServiceOptions, cb containers.CallbackFn) (containers.ContainerCreator, error) {
 
 	var (
 		user  string
@@ -73,40 +90,57 @@ func InitialSetup(projectName string, cb containers.CallbackFn) (containers.Cont
 	initdbDir := utils.MkdirAbs(defaultEntrypoint)
 	initdbDirPath := utils.GetAbsChild(initdbDir)
 	utils.WriteFile(initdbFile, initdbDir, "init-db.sh")
-	databasePath := utils.MkdirAbs("database")
+	//Every service gets its own data directory or volume so databases can coexist in one project
+	name := utils.PrefixProjectName(projectName, ServiceName)
+	dataMount, err := opt.DataMount(name, path.Join("database", ServiceName), "/data/db")
+	if err != nil {
+		return nil, err
+	}
 
 	//Root password 16 char long string, saves user time, to not think about two separate passwords
 	rootPass := utils.GenerateRandomString(16)
-	URL := fmt.Sprintf("mongodb://%s:%s@localhost:%s/%s", user, url.QueryEscape(pass), defaultHostPort, projectName)
-	rootURL := fmt.Sprintf("mongodb://%s:%s@localhost:%s/%s", "root", rootPass, defaultHostPort, "admin")
+	//the default port is only used if no other project on the machine uses it
+	hostPort := opt.HostPort(defaultHostPort)
+	URL := fmt.Sprintf("mongodb://%s:%s@localhost:%s/%s", user, url.QueryEscape(pass), hostPort, projectName)
+	rootURL := fmt.Sprintf("mongodb://%s:%s@localhost:%s/%s", "root", rootPass, hostPort, "admin")
 
 	container := containers.Container{
-		Name:     utils.PrefixProjectName(projectName, "mongodb"),
-		Hostname: fmt.Sprintf("com.%s.mongodb", projectName),
-		Image:    DefaultImgTag,
+		Name:      name,
+		Project:   projectName,
+		Service:   ServiceName,
+		Network:   opt.Network,
+		User:      opt.UserFor(dataMount),
+		Hostname:  fmt.Sprintf("com.%s.mongodb", projectName),
+		Image:     opt.ImageOrDefault(DefaultImgTag),
+		Resources: defaultResources,
+		Restart:   containers.RestartOnFailure,
 		Env: []containers.ContainerEnv{
 			{
-				Key:   "MONGO_INITDB_DATABASE",
+				Key:   "MONGODB_DATABASE",
 				Value: projectName,
 			},
 			{
-				Key:   "MONGO_INITDB_USERNAME",
+				Key:   "MONGODB_USER",
 				Value: user,
 			},
 			{
-				Key:   "MONGO_INITDB_PASSWORD",
+				Key:   "MONGODB_PASSWORD",
 				Value: pass,
 			},
+			{
+				Key:   "MONGODB_PORT",
+				Value: hostPort,
+			},
 			{
 				Key:   "MONGODB_URL",
 				Value: URL,
 			},
 			{
-				Key:   "MONGO_INITDB_ROOT_USERNAME",
+				Key:   "MONGODB_ROOT_USER",
 				Value: "root",
 			},
 			{
-				Key:   "MONGO_INITDB_ROOT_PASSWORD",
+				Key:   "MONGODB_ROOT_PASSWORD",
 				Value: rootPass,
 			},
 
@@ -115,22 +149,25 @@ func InitialSetup(projectName string, cb containers.CallbackFn) (containers.Cont
 				Value: rootURL,
 			},
 		},
+		//the image creates the root user from its MONGO_INITDB_ROOT_* variables, they are exported from the
+		//MONGODB_* keys before the image entrypoint runs so the .env keys of every service share its prefix
+		Cmd: []containers.ContainerCmdArg{
+			{Arg: "sh"},
+			{Arg: "-c"},
+			{Arg: `export MONGO_INITDB_ROOT_USERNAME="$MONGODB_ROOT_USER" MONGO_INITDB_ROOT_PASSWORD="$MONGODB_ROOT_PASSWORD" MONGO_INITDB_DATABASE="$MONGODB_DATABASE"; exec docker-entrypoint.sh mongod`},
+		},
 		Mounts: []containers.ContainerMount{
 			{
 				Type:   mount.TypeBind,
 				Source: initdbDirPath,
 				Tagret: "/docker-entrypoint-initdb.d",
 			},
-			{
-				Type:   mount.TypeBind,
-				Source: databasePath,
-				Tagret: "/data/db",
-			},
+			dataMount,
 		},
 		PortBindings: []containers.ContainerPortBinding{
 			{
 				Port:     "27017",
-				HostPort: defaultHostPort,
+				HostPort: hostPort,
 				HostIP:   "0.0.0.0",
 			},
 		},
@@ -140,8 +177,6 @@ func InitialSetup(projectName string, cb containers.CallbackFn) (containers.Cont
 			},
 		},
 	}
-	//Eg. MONGO_INITDB_USERNAME=admin
-	utils.AppendFileIfNotExists(".env", container.CreateENVKeyPair()...)
-
-	return containers.NewCreateContainerPayload(&container, cb), nil
+	//the .env is only written once the container exists EG. MONGODB_USER=admin
+	return containers.NewCreateContainerPayload(&container, containers.MergeEnvOnCreate(".env", &container, legacyEnvKeys, cb)), nil
 }
@@ -1,11 +1,11 @@
-mongo -- "$MONGO_INITDB_DATABASE" <<EOF
-    var rootUser = '$MONGO_INITDB_ROOT_USERNAME';
-    var rootPassword = '$MONGO_INITDB_ROOT_PASSWORD';
+mongo -- "$MONGODB_DATABASE" <<EOF
+    var rootUser = '$MONGODB_ROOT_USER';
+    var rootPassword = '$MONGODB_ROOT_PASSWORD';
     var admin = db.getSiblingDB('admin');
     admin.auth(rootUser, rootPassword);
 
-    var user = '$MONGO_INITDB_USERNAME';
-    var passwd = '$MONGO_INITDB_PASSWORD';
+    var user = '$MONGODB_USER';
+    var passwd = '$MONGODB_PASSWORD';
     db.createUser({user: user, pwd: passwd, roles: ["readWrite"]});
     
 EOF
\ No newline at end of file
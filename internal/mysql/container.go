@@ -3,23 +3,31 @@ package mysql
 import (
 	"bufio"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 
-	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
 	"github.com/isolateminds/blah/internal/color"
 	"github.com/isolateminds/blah/internal/containers"
 	"github.com/isolateminds/blah/internal/utils"
 )
 
 var (
-	defaultHostPort      = "3186"
+	defaultHostPort      = "3187"
 	defaultContainerName = "mysql"
 
 	DefaultImgTag = "mysql:latest"
+
+	// Development limits so several projects fit on a laptop
+	defaultResources = containers.ContainerResources{Memory: units.GiB, NanoCPUs: 1e9}
 )
 
+// Name of the service used for flags and container names EG. myproj_mysql
+const ServiceName = "mysql"
+
 // Prompts user for mysql authentication details and makes all necessary mount points
-func InitialSetup(projectName string, cb containers.CallbackFn) (containers.ContainerCreator, error) {
+func InitialSetup(projectName string, opt containers.ServiceOptions, cb containers.CallbackFn) (containers.ContainerCreator, error) {
 
 	var (
 		user  string
@@ -50,15 +58,29 @@ func InitialSetup(projectName string, cb containers.CallbackFn) (containers.Cont
 	}
 
 	//Create Database mountpoints
-	databasePath := utils.MkdirAbs("database")
+	//Every service gets its own data directory or volume so databases can coexist in one project
+	name := utils.PrefixProjectName(projectName, ServiceName)
+	dataMount, err := opt.DataMount(name, path.Join("database", ServiceName), "/var/lib/mysql")
+	if err != nil {
+		return nil, err
+	}
 
 	//Root password 16 char long string, saves user time, to not think about two separate passwords
 	rootPass := utils.GenerateRandomString(16)
+	//the default port is only used if no other project on the machine uses it
+	hostPort := opt.HostPort(defaultHostPort)
+	URL := fmt.Sprintf("mysql://%s:%s@localhost:%s/%s", user, url.QueryEscape(pass), hostPort, projectName)
 
 	container := containers.Container{
-		Name:     utils.PrefixProjectName(projectName, "mysql"),
-		Hostname: fmt.Sprintf("com.%s.mysql", projectName),
-		Image:    DefaultImgTag,
+		Name:      name,
+		Project:   projectName,
+		Service:   ServiceName,
+		Network:   opt.Network,
+		User:      opt.UserFor(dataMount),
+		Hostname:  fmt.Sprintf("com.%s.mysql", projectName),
+		Image:     opt.ImageOrDefault(DefaultImgTag),
+		Resources: defaultResources,
+		Restart:   containers.RestartOnFailure,
 		Env: []containers.ContainerEnv{
 			{
 				Key:   "MYSQL_DATABASE",
@@ -79,20 +101,18 @@ func InitialSetup(projectName string, cb containers.CallbackFn) (containers.Cont
 			},
 			{
 				Key:   "MYSQL_PORT",
-				Value: defaultHostPort,
+				Value: hostPort,
 			},
-		},
-		Mounts: []containers.ContainerMount{
 			{
-				Type:   mount.TypeBind,
-				Source: databasePath,
-				Tagret: "/var/lib/mysql",
+				Key:   "MYSQL_URL",
+				Value: URL,
 			},
 		},
+		Mounts: []containers.ContainerMount{dataMount},
 		PortBindings: []containers.ContainerPortBinding{
 			{
 				Port:     "3306",
-				HostPort: defaultHostPort,
+				HostPort: hostPort,
 				HostIP:   "0.0.0.0",
 			},
 		},
@@ -102,8 +122,14 @@ func InitialSetup(projectName string, cb containers.CallbackFn) (containers.Cont
 			},
 		},
 	}
-	//Eg. MYSQL_USER=admin
-	utils.AppendFileIfNotExists(".env", container.CreateENVKeyPair()...)
-
-	return containers.NewCreateContainerPayload(&container, cb), nil
+	if container.User != "" {
+		//the secure-file-priv directory of the image is only accessible to its mysql user,
+		//mysqld refuses to start as any other user unless file import and export is disabled
+		container.Cmd = []containers.ContainerCmdArg{
+			{Arg: "mysqld"},
+			{Arg: "--secure-file-priv=NULL"},
+		}
+	}
+	//the .env is only written once the container exists EG. MYSQL_USER=admin
+	return containers.NewCreateContainerPayload(&container, containers.MergeEnvOnCreate(".env", &container, nil, cb)), nil
 }
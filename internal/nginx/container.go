@@ -3,9 +3,13 @@ package nginx
 import (
 	_ "embed"
 	"fmt"
+	"strings"
 
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
+	"github.com/isolateminds/blah/internal/color"
 	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/devcert"
 	"github.com/isolateminds/blah/internal/utils"
 )
 
@@ -13,20 +17,40 @@ var (
 	//go:embed nginx.conf
 	nginxConf []byte
 
+	defaultHostPort    = "8080"
+	defaultTLSHostPort = "8443"
+
 	DefaultImgTag = "nginx:latest"
+
+	// Upstreams on the host are proxied to host.docker.internal, it only resolves on Docker Desktop
+	// unless it is mapped to the gateway of the host like on Linux
+	hostGateway = "host.docker.internal:host-gateway"
+
+	// Development limits so several projects fit on a laptop
+	defaultResources = containers.ContainerResources{Memory: 128 * units.MiB, NanoCPUs: 5e8}
 )
 
-func InitialSetup(projectName string, fn containers.CallbackFn) (containers.ContainerCreator, error) {
+// Name of the service used for flags and container names EG. myproj_nginx
+const ServiceName = "nginx"
+
+// Writes the default nginx.conf and with opt.TLS a certificate for <project>.localhost signed by the development CA
+func InitialSetup(projectName string, opt containers.ServiceOptions, fn containers.CallbackFn) (containers.ContainerCreator, error) {
 	nginxPath := utils.WriteFileAbs(nginxConf, "nginx.conf")
 	container := containers.Container{
-		Name:     utils.PrefixProjectName(projectName, "nginx"),
-		Image:    DefaultImgTag,
-		Hostname: fmt.Sprintf("com.%s.nginx", projectName),
+		Name:       utils.PrefixProjectName(projectName, ServiceName),
+		Project:    projectName,
+		Service:    ServiceName,
+		Network:    opt.Network,
+		Image:      opt.ImageOrDefault(DefaultImgTag),
+		Resources:  defaultResources,
+		Restart:    containers.RestartOnFailure,
+		Hostname:   fmt.Sprintf("com.%s.nginx", projectName),
+		ExtraHosts: []containers.ContainerExtraHost{{Host: hostGateway}},
 		Mounts: []containers.ContainerMount{
 			{
 				Type:   mount.TypeBind,
 				Source: nginxPath,
-				Tagret: "/etc/nginx/nginx.conf",
+				Tagret: ConfigTarget,
 			},
 		},
 		ExposedPorts: []containers.ContainerExposedPort{
@@ -37,10 +61,62 @@ func InitialSetup(projectName string, fn containers.CallbackFn) (containers.Cont
 		PortBindings: []containers.ContainerPortBinding{
 			{
 				Port:     "80",
-				HostPort: "8080",
+				HostPort: opt.HostPort(defaultHostPort),
 				HostIP:   "0.0.0.0",
 			},
 		},
 	}
+	if opt.TLS {
+		if err := setupTLS(projectName, nginxPath, opt.HostPort(defaultTLSHostPort), &container); err != nil {
+			return nil, err
+		}
+	}
 	return containers.NewCreateContainerPayload(&container, fn), nil
 }
+
+// Issues the project certificate, mounts it and binds 443 to a host port
+func setupTLS(projectName string, nginxPath string, hostPort string, container *containers.Container) error {
+	ca, caKey, err := devcert.LoadOrCreateCA()
+	if err != nil {
+		return err
+	}
+	certsPath := utils.MkdirAbs("certs")
+	if err := devcert.IssueProjectCert(ca, caKey, strings.ToLower(projectName), certsPath); err != nil {
+		return err
+	}
+	//the private key of the certificate should not be committed
+	utils.AppendMissingLines(".gitignore", "certs/")
+
+	container.Mounts = append(container.Mounts, containers.ContainerMount{
+		Type:   mount.TypeBind,
+		Source: certsPath,
+		Tagret: CertsTarget,
+	})
+	container.ExposedPorts = append(container.ExposedPorts, containers.ContainerExposedPort{Port: "443"})
+	container.PortBindings = append(container.PortBindings, containers.ContainerPortBinding{
+		Port:     "443",
+		HostPort: hostPort,
+		HostIP:   "0.0.0.0",
+	})
+	_, err = WriteProxyConfig(nginxPath, ProxyConfig{TLS: true, ServerName: ServerName(projectName)})
+	if err != nil {
+		return err
+	}
+	color.PrintStatus("TLS", fmt.Sprintf("https://%s:%s run blah tls trust to trust the development CA", ServerName(projectName), hostPort))
+	return nil
+}
+
+// Returns the host name of the project EG. myproj.localhost
+func ServerName(projectName string) string {
+	return fmt.Sprintf("%s.localhost", strings.ToLower(projectName))
+}
+
+// Returns true if the container mounts a certificate directory
+func TLSEnabled(c *containers.Container) bool {
+	for i := range c.Mounts {
+		if c.Mounts[i].Tagret == CertsTarget {
+			return true
+		}
+	}
+	return false
+}
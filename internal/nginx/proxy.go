@@ -0,0 +1,183 @@
+package nginx
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/isolateminds/blah/internal/containers"
+)
+
+const (
+	// Path of the bind mounted configuration inside the container
+	ConfigTarget = "/etc/nginx/nginx.conf"
+	// Path of the bind mounted certificate directory inside the container
+	CertsTarget = "/etc/nginx/certs"
+
+	proxyBegin = "    # BEGIN blah proxy (generated by blah proxy add, edits between these lines are overwritten)\n"
+	proxyEnd   = "    # END blah proxy\n"
+)
+
+var (
+	includeRGX = regexp.MustCompile(`(?m)^\s*include\s+([^;\s]+)\s*;`)
+	pathRGX    = regexp.MustCompile(`^/[A-Za-z0-9._~!$&'()*+,=:@%/-]*$`)
+	hostRGX    = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?\.)*[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?$`)
+
+	// Path routes share a server with server_name localhost, it comes before the include of conf.d
+	// so that it takes precedence over the default.conf of the nginx image. With TLS the certificate
+	// is set on the http block so every server of the section can listen on 443
+	proxyTmpl = template.Must(template.New("proxy").Parse(`{{- if .TLS}}
+    ssl_certificate     {{.CertsTarget}}/cert.pem;
+    ssl_certificate_key {{.CertsTarget}}/key.pem;
+{{- end}}
+{{- if or .Paths .TLS}}
+
+    server {
+{{- if .Paths}}
+        listen 80;
+{{- end}}
+{{- if .TLS}}
+        listen 443 ssl;
+{{- end}}
+        server_name localhost{{if .ServerName}} {{.ServerName}}{{end}};
+        root /usr/share/nginx/html;
+{{- range .Paths}}
+
+        location {{.Location}} {
+            proxy_pass {{.Upstream}};
+{{- template "headers"}}
+        }
+{{- end}}
+    }
+{{- end}}
+{{- range .Hosts}}
+
+    server {
+        listen 80;
+{{- if $.TLS}}
+        listen 443 ssl;
+{{- end}}
+        server_name {{.Pattern}};
+
+        location / {
+            proxy_pass {{.Upstream}};
+{{- template "headers"}}
+        }
+    }
+{{- end}}
+{{define "headers"}}
+            proxy_http_version 1.1;
+            proxy_set_header Host $host;
+            proxy_set_header X-Real-IP $remote_addr;
+            proxy_set_header X-Forwarded-For $proxy_add_x_forwarded_for;
+            proxy_set_header X-Forwarded-Proto $scheme;
+            proxy_set_header Upgrade $http_upgrade;
+            proxy_set_header Connection "upgrade";
+{{- end}}
+`))
+)
+
+// Everything that is rendered into the generated section of nginx.conf
+type ProxyConfig struct {
+	Routes []containers.ProxyRoute
+	// Serve HTTPS with the certificate mounted at CertsTarget
+	TLS bool
+	// Additional server name of the default server EG. myproj.localhost
+	ServerName string
+}
+
+// Validates a route, upstreams without a scheme default to http EG. host.docker.internal:3000
+func NewProxyRoute(pattern string, upstream string) (*containers.ProxyRoute, error) {
+	if !pathRGX.MatchString(pattern) && !hostRGX.MatchString(pattern) {
+		return nil, fmt.Errorf("%s is neither a path EG. /api nor a host EG. api.myproj.localhost", pattern)
+	}
+	if !strings.Contains(upstream, "://") {
+		upstream = "http://" + upstream
+	}
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.ContainsAny(upstream, " ;{}") {
+		return nil, fmt.Errorf("%s is not a valid upstream EG. http://host.docker.internal:3000", upstream)
+	}
+	return &containers.ProxyRoute{Pattern: pattern, Upstream: upstream}, nil
+}
+
+// Renders the server blocks of the configuration
+func RenderProxyConfig(cfg ProxyConfig) ([]byte, error) {
+	data := struct {
+		ProxyConfig
+		CertsTarget  string
+		Paths, Hosts []containers.ProxyRoute
+	}{ProxyConfig: cfg, CertsTarget: CertsTarget}
+	for i := range cfg.Routes {
+		if cfg.Routes[i].IsHost() {
+			data.Hosts = append(data.Hosts, cfg.Routes[i])
+		} else {
+			data.Paths = append(data.Paths, cfg.Routes[i])
+		}
+	}
+	var buf bytes.Buffer
+	if err := proxyTmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Replaces the generated section of an nginx.conf with the rendered routes. Configurations
+// without the section get it appended before the closing brace of the http block
+func ReplaceProxySection(conf []byte, rendered []byte) ([]byte, error) {
+	s := string(conf)
+	section := proxyBegin + proxyEnd
+	if blocks := strings.Trim(string(rendered), "\n"); blocks != "" {
+		section = proxyBegin + blocks + "\n" + proxyEnd
+	}
+	begin := strings.Index(s, proxyBegin)
+	end := strings.Index(s, proxyEnd)
+	if begin >= 0 && end > begin {
+		return []byte(s[:begin] + section + s[end+len(proxyEnd):]), nil
+	}
+	closing := strings.LastIndex(s, "}")
+	if closing < 0 {
+		return nil, fmt.Errorf("Could not find the http block of nginx.conf")
+	}
+	return []byte(s[:closing] + "\n" + section + s[closing:]), nil
+}
+
+// Writes the configuration into the nginx.conf at confPath and returns the previous content so it can be restored.
+// The file is written in place because a bind mounted file that is replaced is not seen by the container
+func WriteProxyConfig(confPath string, cfg ProxyConfig) ([]byte, error) {
+	previous, err := ioutil.ReadFile(confPath)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := RenderProxyConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := ReplaceProxySection(previous, rendered)
+	if err != nil {
+		return nil, err
+	}
+	return previous, ioutil.WriteFile(confPath, conf, 0666)
+}
+
+// Returns the paths inside the container of every file included by the configuration
+// EG. /etc/nginx/conf.d/*.conf, relative paths are resolved against /etc/nginx
+func IncludedFiles(conf []byte) []string {
+	var files []string
+	for _, match := range includeRGX.FindAllSubmatch(conf, -1) {
+		file := strings.Trim(string(match[1]), `"'`)
+		if !strings.HasPrefix(file, "/") {
+			file = path.Join(path.Dir(ConfigTarget), file)
+		}
+		files = append(files, file)
+	}
+	return files
+}
@@ -0,0 +1,202 @@
+package nginx
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/isolateminds/blah/internal/containers"
+)
+
+func TestNewProxyRoute(t *testing.T) {
+	tests := []struct {
+		pattern, upstream string
+		want              string
+		wantErr           bool
+	}{
+		{"/api", "host.docker.internal:3000", "http://host.docker.internal:3000", false},
+		{"/api/v1/", "https://app:8443", "https://app:8443", false},
+		{"api.myproj.localhost", "http://host.docker.internal:3000", "http://host.docker.internal:3000", false},
+		{"api", "app:3000", "http://app:3000", false},
+		{"/api;", "app:3000", "", true},
+		{"-api.localhost", "app:3000", "", true},
+		{"/api", "ftp://app:21", "", true},
+		{"/api", "http://", "", true},
+		{"/api", "app:3000; return 200", "", true},
+		{"/api", "app:3000}", "", true},
+	}
+	for _, tt := range tests {
+		route, err := NewProxyRoute(tt.pattern, tt.upstream)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewProxyRoute(%q, %q) error = %v, wantErr %v", tt.pattern, tt.upstream, err, tt.wantErr)
+			continue
+		}
+		if err == nil && route.Upstream != tt.want {
+			t.Errorf("NewProxyRoute(%q, %q) upstream = %q, want %q", tt.pattern, tt.upstream, route.Upstream, tt.want)
+		}
+	}
+}
+
+func TestRenderProxyConfig(t *testing.T) {
+	api := containers.ProxyRoute{Pattern: "/api", Upstream: "http://host.docker.internal:3000"}
+	host := containers.ProxyRoute{Pattern: "api.myproj.localhost", Upstream: "http://app:8080"}
+	tests := []struct {
+		name    string
+		cfg     ProxyConfig
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "empty",
+			cfg:  ProxyConfig{},
+		},
+		{
+			name:    "path",
+			cfg:     ProxyConfig{Routes: []containers.ProxyRoute{api}},
+			want:    []string{"listen 80;", "server_name localhost;", "location /api/ {", "proxy_pass http://host.docker.internal:3000;"},
+			notWant: []string{"443", "ssl_certificate"},
+		},
+		{
+			name:    "host",
+			cfg:     ProxyConfig{Routes: []containers.ProxyRoute{host}},
+			want:    []string{"server_name api.myproj.localhost;", "location / {", "proxy_pass http://app:8080;"},
+			notWant: []string{"server_name localhost", "443"},
+		},
+		{
+			name: "tls without routes",
+			cfg:  ProxyConfig{TLS: true, ServerName: "myproj.localhost"},
+			want: []string{
+				"ssl_certificate     /etc/nginx/certs/cert.pem;",
+				"ssl_certificate_key /etc/nginx/certs/key.pem;",
+				"listen 443 ssl;",
+				"server_name localhost myproj.localhost;",
+			},
+			notWant: []string{"listen 80;", "location"},
+		},
+		{
+			name: "tls with path and host",
+			cfg:  ProxyConfig{Routes: []containers.ProxyRoute{host, api}, TLS: true, ServerName: "myproj.localhost"},
+			want: []string{"listen 80;", "location /api/ {", "server_name api.myproj.localhost;"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderProxyConfig(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rendered := string(got)
+			if len(tt.want) == 0 && strings.TrimSpace(rendered) != "" {
+				t.Errorf("RenderProxyConfig() = %q, want nothing", rendered)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(rendered, want) {
+					t.Errorf("RenderProxyConfig() is missing %q\n%s", want, rendered)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(rendered, notWant) {
+					t.Errorf("RenderProxyConfig() contains %q\n%s", notWant, rendered)
+				}
+			}
+			if strings.Count(rendered, "{") != strings.Count(rendered, "}") {
+				t.Errorf("RenderProxyConfig() has unbalanced braces\n%s", rendered)
+			}
+		})
+	}
+}
+
+func TestReplaceProxySection(t *testing.T) {
+	server := "    server {\n        listen 80;\n    }\n"
+	tests := []struct {
+		name     string
+		conf     string
+		rendered string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "fills empty section",
+			conf:     "http {\n" + proxyBegin + proxyEnd + "    include conf.d/*.conf;\n}\n",
+			rendered: "\n" + server,
+			want:     "http {\n" + proxyBegin + server + proxyEnd + "    include conf.d/*.conf;\n}\n",
+		},
+		{
+			name:     "replaces existing section",
+			conf:     "http {\n" + proxyBegin + "    old\n" + proxyEnd + "}\n",
+			rendered: server,
+			want:     "http {\n" + proxyBegin + server + proxyEnd + "}\n",
+		},
+		{
+			name:     "empties section",
+			conf:     "http {\n" + proxyBegin + "    old\n" + proxyEnd + "}\n",
+			rendered: "\n",
+			want:     "http {\n" + proxyBegin + proxyEnd + "}\n",
+		},
+		{
+			name:     "appends missing section",
+			conf:     "http {\n    sendfile on;\n}\n",
+			rendered: server,
+			want:     "http {\n    sendfile on;\n\n" + proxyBegin + server + proxyEnd + "}\n",
+		},
+		{
+			name:     "no http block",
+			conf:     "events {",
+			rendered: server,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReplaceProxySection([]byte(tt.conf), []byte(tt.rendered))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReplaceProxySection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReplaceProxySection()\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceProxySectionDefaultConf(t *testing.T) {
+	rendered, err := RenderProxyConfig(ProxyConfig{Routes: []containers.ProxyRoute{{Pattern: "/api", Upstream: "http://app:3000"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	once, err := ReplaceProxySection(nginxConf, rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	twice, err := ReplaceProxySection(once, rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(once) != string(twice) {
+		t.Errorf("replacing the section twice changed the configuration\n%s\n%s", once, twice)
+	}
+	emptied, err := ReplaceProxySection(once, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(emptied) != string(nginxConf) {
+		t.Errorf("removing every route did not restore the default configuration\n%s", emptied)
+	}
+}
+
+func TestIncludedFiles(t *testing.T) {
+	tests := []struct {
+		conf string
+		want []string
+	}{
+		{string(nginxConf), []string{"/etc/nginx/mime.types", "/etc/nginx/conf.d/*.conf"}},
+		{"include snippets/proxy.conf;\n  include \"/etc/nginx/extra.conf\" ;", []string{"/etc/nginx/snippets/proxy.conf", "/etc/nginx/extra.conf"}},
+		{"# include commented.conf;\nserver { listen 80; }", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := IncludedFiles([]byte(tt.conf)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("IncludedFiles(%q) = %q, want %q", tt.conf, got, tt.want)
+		}
+	}
+}
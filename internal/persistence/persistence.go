@@ -1,9 +1,13 @@
 package persistence
 
 import (
+	"path/filepath"
+
 	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/profile"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PersistedDataController struct{ db *gorm.DB }
@@ -14,7 +18,17 @@ func (c *PersistedDataController) Persist(object any) error {
 
 func (c *PersistedDataController) GetAllContainers() ([]*containers.Container, error) {
 	var containers []*containers.Container
-	tx := c.db.Find(&containers)
+	tx := c.db.Preload(clause.Associations).Find(&containers)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return containers, nil
+}
+
+// Returns the containers of a profile, the default profile is stored as an empty string
+func (c *PersistedDataController) GetProfileContainers(profile string) ([]*containers.Container, error) {
+	var containers []*containers.Container
+	tx := c.db.Preload(clause.Associations).Where("profile = ?", profile).Find(&containers)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -30,6 +44,27 @@ func (c *PersistedDataController) FindOneContainerByID(ID string) (*containers.C
 	return &container, nil
 }
 
+// Finds the container of a service in a profile EG. mongodb, containers persisted before services were
+// recorded are matched by their name instead EG. myproj_mongodb
+func (c *PersistedDataController) FindContainerByService(profile string, service string, name string) (*containers.Container, error) {
+	var container containers.Container
+	tx := c.db.Preload(clause.Associations).Where("profile = ? AND (service = ? OR name = ?)", profile, service, name).First(&container)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return &container, nil
+}
+
+// Deletes a persisted container from sqlite file along with all of its associations
+func (c *PersistedDataController) DeleteContainer(container *containers.Container) error {
+	return c.db.Unscoped().Select(clause.Associations).Delete(container).Error
+}
+
+// Deletes the persisted env of a container so a new env is saved with it instead of being added to the old one
+func (c *PersistedDataController) DeleteContainerEnv(container *containers.Container) error {
+	return c.db.Unscoped().Where("env_refer = ?", container.ID).Delete(&containers.ContainerEnv{}).Error
+}
+
 // Deletes a persisted container from sqlite file along with its assosiated mount points
 func (c *PersistedDataController) DeleteContainerByID(ID string) error {
 
@@ -40,18 +75,68 @@ func (c *PersistedDataController) DeleteContainerByID(ID string) error {
 	return nil
 }
 
-// Opens a sqlite file and returns a controller to manage persistence
+func (c *PersistedDataController) GetAllProxyRoutes() ([]containers.ProxyRoute, error) {
+	var routes []containers.ProxyRoute
+	tx := c.db.Order("id").Find(&routes)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return routes, nil
+}
+
+// Deletes a proxy route by its path or host
+func (c *PersistedDataController) DeleteProxyRoute(pattern string) error {
+	tx := c.db.Unscoped().Where("pattern = ?", pattern).Delete(&containers.ProxyRoute{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (c *PersistedDataController) GetAllProfiles() ([]profile.Profile, error) {
+	var profiles []profile.Profile
+	tx := c.db.Order("id").Find(&profiles)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return profiles, nil
+}
+
+func (c *PersistedDataController) FindProfile(name string) (*profile.Profile, error) {
+	var p profile.Profile
+	tx := c.db.Where("name = ?", name).First(&p)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return &p, nil
+}
+
+// Opens a sqlite file and returns a controller to manage persistence. The file is in the project
+// directory so its name is the name of the project
 func NewPersistedDataController(name string) (*PersistedDataController, error) {
 	db, err := gorm.Open(sqlite.Open(name), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
 
 	db.AutoMigrate(&containers.Container{})
 	db.AutoMigrate(&containers.ContainerMount{})
 	db.AutoMigrate(&containers.ContainerExposedPort{})
 	db.AutoMigrate(&containers.ContainerPortBinding{})
 	db.AutoMigrate(&containers.ContainerEnv{})
-
-	if err != nil {
-		return nil, err
+	db.AutoMigrate(&containers.ContainerCmdArg{})
+	db.AutoMigrate(&containers.ContainerResources{})
+	db.AutoMigrate(&containers.ContainerExtraHost{})
+	db.AutoMigrate(&containers.ProxyRoute{})
+	db.AutoMigrate(&profile.Profile{})
+	//containers persisted before profiles existed belong to the default profile
+	db.Model(&containers.Container{}).Where("profile IS NULL").Update("profile", "")
+	//containers persisted before projects were recorded belong to the project of the file
+	if abs, err := filepath.Abs(name); err == nil {
+		db.Model(&containers.Container{}).Where("project IS NULL OR project = ''").Update("project", filepath.Base(filepath.Dir(abs)))
 	}
 	return &PersistedDataController{db}, nil
 }
@@ -0,0 +1,147 @@
+package profile
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Name of the profile the project is created with, its containers are stored with an empty profile
+const Default = "dev"
+
+// Host ports of a profile are shifted by a multiple of this so profiles can run side by side
+const portOffsetStep = 100
+
+// A set of containers with the same topology as the default profile but their own
+// ports, data directories, network and .env EG. test or ci
+type Profile struct {
+	gorm.Model
+	Name       string `gorm:"uniqueIndex" json:"name"`
+	PortOffset int    `json:"portOffset"`
+}
+
+// Returns an error if name can not be used as a profile name
+func Validate(name string) error {
+	if name == "" || !utils.IsAlphaNumeric(name) || strings.ToLower(name) != name {
+		return fmt.Errorf("Profile name should be lower case alpha numeric not %q", name)
+	}
+	return nil
+}
+
+// Returns the profile stored on containers, the default profile is stored as an empty string
+func Stored(name string) string {
+	if name == Default {
+		return ""
+	}
+	return name
+}
+
+// Returns the env file of a profile EG. .env or .env.test
+func EnvFile(name string) string {
+	if Stored(name) == "" {
+		return ".env"
+	}
+	return ".env." + name
+}
+
+// Returns the container name of a service in a profile EG. myproj_mongodb or myproj_test_mongodb
+func ContainerName(projectName string, name string, service string) string {
+	if Stored(name) == "" {
+		return utils.PrefixProjectName(projectName, service)
+	}
+	return utils.PrefixProjectName(projectName, name+"_"+service)
+}
+
+// Returns the network of a profile EG. myproj_default or myproj_test_default. Every profile has its own
+// network so the containers of all profiles can use the service name as their alias
+func Network(projectName string, name string) string {
+	return ContainerName(projectName, name, "default")
+}
+
+// Returns the smallest port offset that is not used by one of the profiles
+func NextPortOffset(profiles []Profile) int {
+	used := make(map[int]bool)
+	for i := range profiles {
+		used[profiles[i].PortOffset] = true
+	}
+	offset := portOffsetStep
+	for used[offset] {
+		offset += portOffsetStep
+	}
+	return offset
+}
+
+// Copies the configuration of a default profile container into the profile. Host ports are shifted
+// by the port offset, the env is rewritten to the new ports and data directories move to database/<profile>.
+// Named data volumes are replaced by the volume of the profile container EG. myproj_test_mongodb_data
+func (p Profile) Clone(projectName string, c *containers.Container) containers.Container {
+	service := c.Service
+	if service == "" {
+		service = strings.TrimPrefix(c.Name, utils.PrefixProjectName(projectName, ""))
+	}
+	clone := containers.Container{
+		Name:         ContainerName(projectName, p.Name, service),
+		Project:      projectName,
+		Service:      service,
+		Profile:      p.Name,
+		Image:        c.Image,
+		ImageDigest:  c.ImageDigest,
+		Hostname:     c.Hostname,
+		Network:      Network(projectName, p.Name),
+		WorkingDir:   c.WorkingDir,
+		BuildContext: c.BuildContext,
+		User:         c.User,
+		Restart:      c.Restart,
+		Resources:    containers.ContainerResources{Memory: c.Resources.Memory, NanoCPUs: c.Resources.NanoCPUs},
+	}
+
+	addresses := make(map[string]string)
+	ports := make(map[string]string)
+	for _, binding := range c.PortBindings {
+		hostPort := binding.HostPort
+		if n, err := strconv.Atoi(binding.HostPort); err == nil {
+			hostPort = strconv.Itoa(n + p.PortOffset)
+		}
+		ports[binding.HostPort] = hostPort
+		addresses["localhost:"+binding.HostPort] = "localhost:" + hostPort
+		clone.PortBindings = append(clone.PortBindings, containers.ContainerPortBinding{
+			Port:     binding.Port,
+			HostPort: hostPort,
+			HostIP:   binding.HostIP,
+		})
+	}
+	for _, env := range c.Env {
+		value := utils.ReplaceAddresses(env.Value, addresses)
+		if port, ok := ports[env.Value]; ok && strings.HasSuffix(env.Key, "_PORT") {
+			value = port
+		}
+		clone.Env = append(clone.Env, containers.ContainerEnv{Key: env.Key, Value: value})
+	}
+
+	databasePath := utils.GetAbsChild("database")
+	for _, m := range c.Mounts {
+		source := m.Source
+		if m.Type == mount.TypeVolume {
+			source = containers.DataVolume(clone.Name)
+		} else if rel, err := filepath.Rel(databasePath, m.Source); err == nil && !strings.HasPrefix(rel, "..") {
+			source = utils.MkdirAllAbs(filepath.Join("database", p.Name, rel))
+		}
+		clone.Mounts = append(clone.Mounts, containers.ContainerMount{Type: m.Type, Source: source, Tagret: m.Tagret})
+	}
+	for _, port := range c.ExposedPorts {
+		clone.ExposedPorts = append(clone.ExposedPorts, containers.ContainerExposedPort{Port: port.Port})
+	}
+	for _, arg := range c.Cmd {
+		clone.Cmd = append(clone.Cmd, containers.ContainerCmdArg{Arg: arg.Arg})
+	}
+	for _, extraHost := range c.ExtraHosts {
+		clone.ExtraHosts = append(clone.ExtraHosts, containers.ContainerExtraHost{Host: extraHost.Host})
+	}
+	return clone
+}
@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/docker/go-units"
+	"github.com/isolateminds/blah/internal/color"
+	"github.com/isolateminds/blah/internal/containers"
+	"github.com/isolateminds/blah/internal/utils"
+)
+
+var (
+	defaultHostPort = "6380"
+
+	DefaultImgTag = "redis:latest"
+
+	// Development limits so several projects fit on a laptop
+	defaultResources = containers.ContainerResources{Memory: 256 * units.MiB, NanoCPUs: 5e8}
+)
+
+// Name of the service used for flags and container names EG. myproj_redis
+const ServiceName = "redis"
+
+// Prompts user whether redis data should be persisted and makes all necessary mount points.
+// A password is always generated and required by the redis server
+func InitialSetup(projectName string, opt containers.ServiceOptions, cb containers.CallbackFn) (containers.ContainerCreator, error) {
+	color.PrintStatus("Redis", "Setup your Redis cache")
+	reader := bufio.NewReader(os.Stdin)
+
+	persist, err := utils.GetConfirmation(reader, "Persist data to disk with AOF? (y/n): ")
+	if err != nil {
+		return nil, err
+	}
+
+	//Password 16 char long string redis is only used by the project so nobody has to remember it
+	pass := utils.GenerateRandomString(16)
+	//the default port is only used if no other project on the machine uses it
+	hostPort := opt.HostPort(defaultHostPort)
+	URL := fmt.Sprintf("redis://:%s@localhost:%s/0", pass, hostPort)
+
+	container := containers.Container{
+		Name:      utils.PrefixProjectName(projectName, ServiceName),
+		Project:   projectName,
+		Service:   ServiceName,
+		Network:   opt.Network,
+		Hostname:  fmt.Sprintf("com.%s.redis", projectName),
+		Image:     opt.ImageOrDefault(DefaultImgTag),
+		Resources: defaultResources,
+		Restart:   containers.RestartOnFailure,
+		Env: []containers.ContainerEnv{
+			{
+				Key:   "REDIS_PASSWORD",
+				Value: pass,
+			},
+			{
+				Key:   "REDIS_PORT",
+				Value: hostPort,
+			},
+			{
+				Key:   "REDIS_URL",
+				Value: URL,
+			},
+		},
+		//the password is read from the env when the server starts so it is not part of the command that is
+		//persisted and shown by docker inspect, the image entrypoint still drops root before redis-server runs.
+		//The options appended below are passed on as "$@"
+		Cmd: []containers.ContainerCmdArg{
+			{Arg: "sh"},
+			{Arg: "-c"},
+			{Arg: `exec docker-entrypoint.sh redis-server --requirepass "$REDIS_PASSWORD" "$@"`},
+			{Arg: "redis-server"},
+		},
+		PortBindings: []containers.ContainerPortBinding{
+			{
+				Port:     "6379",
+				HostPort: hostPort,
+				HostIP:   "0.0.0.0",
+			},
+		},
+		ExposedPorts: []containers.ContainerExposedPort{
+			{
+				Port: "6379",
+			},
+		},
+	}
+
+	if persist {
+		//Create Database mountpoints
+		dataMount, err := opt.DataMount(container.Name, path.Join("database", ServiceName), "/data")
+		if err != nil {
+			return nil, err
+		}
+		container.Mounts = append(container.Mounts, dataMount)
+		container.User = opt.UserFor(dataMount)
+		container.Cmd = append(container.Cmd,
+			containers.ContainerCmdArg{Arg: "--appendonly"},
+			containers.ContainerCmdArg{Arg: "yes"},
+		)
+	} else {
+		//Nothing is written to disk, the cache starts empty every time the container is recreated
+		container.Cmd = append(container.Cmd,
+			containers.ContainerCmdArg{Arg: "--appendonly"},
+			containers.ContainerCmdArg{Arg: "no"},
+			containers.ContainerCmdArg{Arg: "--save"},
+			containers.ContainerCmdArg{Arg: ""},
+		)
+	}
+
+	//the .env is only written once the container exists EG. REDIS_URL=redis://:password@localhost:6380/0
+	return containers.NewCreateContainerPayload(&container, containers.MergeEnvOnCreate(".env", &container, nil, cb)), nil
+}
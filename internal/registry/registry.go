@@ -0,0 +1,196 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/isolateminds/blah/internal/utils"
+)
+
+const (
+	// How long a command waits for another command to release the registry
+	lockTimeout = 10 * time.Second
+	// A lock older than this was left behind by a command that was killed
+	lockStale = time.Minute
+	lockRetry = 50 * time.Millisecond
+)
+
+// A project on the machine, recorded when it is created and whenever its services change
+type Project struct {
+	Name string `json:"name"`
+	// Absolute path of the project directory EG. /home/me/myproj
+	Path     string   `json:"path"`
+	Services []string `json:"services"`
+	// Host ports bound by the containers of every profile of the project
+	Ports []string `json:"ports"`
+}
+
+// Returns true if the project directory still holds its persist.db
+func (p Project) Exists() bool {
+	return utils.FileExists(filepath.Join(p.Path, "persist.db"))
+}
+
+// The projects of the user, kept in $XDG_CONFIG_HOME/blah/projects.json EG. ~/.config/blah/projects.json
+type Registry struct {
+	path     string
+	Projects []Project `json:"projects"`
+}
+
+// Returns the path of the registry file
+func Path() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "blah", "projects.json"), nil
+}
+
+// Reads the registry, it is empty if no project was recorded yet
+func Load() (*Registry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return load(path)
+}
+
+func load(path string) (*Registry, error) {
+	r := &Registry{path: path}
+	b, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Locks the registry, reads it, applies fn and writes it back so blah commands running at the same
+// time do not overwrite each other's changes. Nothing is written if fn returns an error
+func Update(fn func(r *Registry) error) (*Registry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lock(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	r, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(r); err != nil {
+		return nil, err
+	}
+	if err := r.Save(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Creates the lock file next to the registry EG. projects.json.lock and returns a function that removes it
+func lock(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Timed out waiting for %s, remove it if no other blah command is running", lockPath)
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// Writes the registry atomically, projects are sorted by name
+func (r *Registry) Save() error {
+	sort.Slice(r.Projects, func(i, j int) bool {
+		if r.Projects[i].Name != r.Projects[j].Name {
+			return r.Projects[i].Name < r.Projects[j].Name
+		}
+		return r.Projects[i].Path < r.Projects[j].Path
+	})
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(r.path, append(b, '\n'), 0600)
+}
+
+// Adds the project or replaces the project recorded for the same path
+func (r *Registry) Register(p Project) {
+	for i := range r.Projects {
+		if r.Projects[i].Path == p.Path {
+			r.Projects[i] = p
+			return
+		}
+	}
+	r.Projects = append(r.Projects, p)
+}
+
+// Returns the projects with the name, projects in different directories can share a name
+func (r *Registry) Find(name string) []Project {
+	var found []Project
+	for _, p := range r.Projects {
+		if p.Name == name {
+			found = append(found, p)
+		}
+	}
+	return found
+}
+
+// Removes the projects whose directory no longer holds a persist.db and returns them
+func (r *Registry) Prune() []Project {
+	var kept, pruned []Project
+	for _, p := range r.Projects {
+		if p.Exists() {
+			kept = append(kept, p)
+		} else {
+			pruned = append(pruned, p)
+		}
+	}
+	r.Projects = kept
+	return pruned
+}
+
+// Returns the host ports bound by the projects other than the project at path
+func (r *Registry) UsedPorts(path string) map[string]bool {
+	used := make(map[string]bool)
+	for _, p := range r.Projects {
+		if p.Path == path {
+			continue
+		}
+		for _, port := range p.Ports {
+			used[port] = true
+		}
+	}
+	return used
+}
@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateConcurrent(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := Update(func(r *Registry) error {
+				r.Register(Project{Name: fmt.Sprintf("proj%02d", i), Path: fmt.Sprintf("/src/proj%02d", i)})
+				return nil
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Projects) != 20 {
+		t.Errorf("got %d projects, want 20", len(r.Projects))
+	}
+	path, _ := Path()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("the lock file was left behind: %v", err)
+	}
+}
+
+func TestUpdateError(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if _, err := Update(func(r *Registry) error {
+		r.Register(Project{Name: "myproj", Path: "/src/myproj"})
+		return fmt.Errorf("failed")
+	}); err == nil {
+		t.Fatal("Update() did not return the error of fn")
+	}
+	r, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Projects) != 0 {
+		t.Errorf("Update() saved the registry although fn failed: %+v", r.Projects)
+	}
+}
+
+func TestLockStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "projects.json")
+	if err := ioutil.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlock()
+}
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "persist.db"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := &Registry{}
+	r.Register(Project{Name: "myproj", Path: dir, Ports: []string{"3186"}})
+	r.Register(Project{Name: "myproj", Path: "/moved/myproj", Ports: []string{"3306"}})
+	r.Register(Project{Name: "other", Path: "/src/other", Ports: []string{"6379"}})
+	r.Register(Project{Name: "myproj", Path: dir, Ports: []string{"3187"}})
+
+	if got := len(r.Find("myproj")); got != 2 {
+		t.Errorf("Find(myproj) returned %d projects, want 2", got)
+	}
+	if got, want := r.UsedPorts(dir), map[string]bool{"3306": true, "6379": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("UsedPorts() = %v, want %v", got, want)
+	}
+	pruned := r.Prune()
+	if len(pruned) != 2 || len(r.Projects) != 1 || r.Projects[0].Path != dir || r.Projects[0].Ports[0] != "3187" {
+		t.Errorf("Prune() pruned %+v and kept %+v", pruned, r.Projects)
+	}
+}
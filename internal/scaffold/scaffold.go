@@ -0,0 +1,98 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+var (
+	//go:embed templates
+	templates embed.FS
+
+	// Languages a source directory can be scaffolded for
+	Languages = []string{"go"}
+)
+
+// Services of the project the generated code connects to
+type Options struct {
+	// Module path of the generated go.mod EG. myproj
+	Module  string
+	MongoDB bool
+	MySQL   bool
+	Redis   bool
+}
+
+// Returns true if the project has a service the db package connects to
+func (o Options) HasServices() bool {
+	return o.MongoDB || o.MySQL || o.Redis
+}
+
+// Returns an error if lang can not be scaffolded
+func Validate(lang string) error {
+	for i := range Languages {
+		if Languages[i] == lang {
+			return nil
+		}
+	}
+	return fmt.Errorf("Can not scaffold %s should be one of %s", lang, strings.Join(Languages, ", "))
+}
+
+// Renders the templates of lang into dir and returns the written files.
+// Files that already exist are kept so user code is never overwritten
+func Generate(lang string, dir string, opt Options) ([]string, error) {
+	if err := Validate(lang); err != nil {
+		return nil, err
+	}
+	root := path.Join("templates", lang)
+	var written []string
+	err := fs.WalkDir(templates, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(name, root+"/"), ".tmpl")
+		if strings.HasPrefix(rel, "db/") && !opt.HasServices() {
+			return nil
+		}
+		target := filepath.Join(dir, filepath.FromSlash(rel))
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+		content, err := render(name, opt)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, content, 0644); err != nil {
+			return err
+		}
+		written = append(written, target)
+		return nil
+	})
+	return written, err
+}
+
+// Executes a template, go source is formatted so the conditional sections leave no stray blank lines
+func render(name string, opt Options) ([]byte, error) {
+	tmpl, err := template.ParseFS(templates, name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, opt); err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(name, ".go.tmpl") {
+		return format.Source(buf.Bytes())
+	}
+	return buf.Bytes(), nil
+}
@@ -37,6 +37,19 @@ func IsAlphaNumeric(check string) bool {
 	return fn(check)
 }
 
+var hostPortRegexp = regexp.MustCompile(`[A-Za-z0-9._-]+:[0-9]+`)
+
+// Replaces the host:port addresses in s that are keys of addresses EG. localhost:3186. A whole address has
+// to match so localhost:3186 does not change localhost:31860, and every address is replaced at most once
+func ReplaceAddresses(s string, addresses map[string]string) string {
+	return hostPortRegexp.ReplaceAllStringFunc(s, func(address string) string {
+		if replacement, ok := addresses[address]; ok {
+			return replacement
+		}
+		return address
+	})
+}
+
 // Gets user input from stdin
 func GetInput(reader *bufio.Reader, output string, inputVar *string, hide bool, reason string) error {
 	color.PrintForInput(output)
@@ -62,6 +75,27 @@ func GetInput(reader *bufio.Reader, output string, inputVar *string, hide bool,
 	}
 	return nil
 }
+
+// Gets a line of user input from stdin without validating it, surrounding whitespace is trimmed
+func GetRawInput(reader *bufio.Reader, output string) (string, error) {
+	color.PrintForInput(output)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
+}
+
+// Asks a yes or no question and returns true if the answer is y or yes
+func GetConfirmation(reader *bufio.Reader, output string) (bool, error) {
+	input, err := GetRawInput(reader, output)
+	if err != nil {
+		return false, err
+	}
+	input = strings.ToLower(input)
+	return input == "y" || input == "yes", nil
+}
+
 func GenerateRandomString(length int) string {
 	rand.Seed(time.Now().Unix() + rand.Int63())
 	b := make([]rune, length)
@@ -94,6 +128,14 @@ func MkdirAbs(path string) string {
 	return GetAbsChild(path)
 }
 
+// Makes directory along with any missing parents returns absolute path fatally exists if an error is encountered
+func MkdirAllAbs(path string) string {
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		color.PrintFatal(err)
+	}
+	return GetAbsChild(path)
+}
+
 // Makes directory
 func Mkdir(path string) {
 	err := os.Mkdir(path, os.ModePerm)
@@ -144,34 +186,61 @@ func FileExists(file string) bool {
 	return true
 }
 
-// Appends lines to a file if it exists
-func AppendFileIfExists(fileName string, lines ...string) {
-	if FileExists(fileName) {
-		AppendFile(fileName, lines...)
+// Appends the lines a file does not contain yet and creates it if needed, running it again does not
+// duplicate lines. Fatally exits if an error is encountered
+func AppendMissingLines(fileName string, lines ...string) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		color.PrintFatal(err)
 	}
-}
-
-// Creates and appends to a file if it does not exist
-func AppendFileIfNotExists(fileName string, lines ...string) {
-	if !FileExists(fileName) {
-		AppendFile(fileName, lines...)
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(b), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+	content := string(b)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	for i := range lines {
+		if !existing[lines[i]] {
+			existing[lines[i]] = true
+			content += lines[i] + "\n"
+		}
+	}
+	if content == string(b) {
+		return
+	}
+	if err := WriteFileAtomic(fileName, []byte(content), 0644); err != nil {
+		color.PrintFatal(err)
 	}
 }
 
-// Appends lines to a file fatally exists if an error is encountered
-func AppendFile(fileName string, lines ...string) {
-
-	f, err := os.OpenFile(fileName, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+// Writes a file to a temporary file in the same directory and renames it over the target,
+// readers see either the old or the new content. The mode of an existing file is kept
+func WriteFileAtomic(fileName string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(fileName); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp")
 	if err != nil {
-		color.PrintFatal(err)
+		return err
 	}
-	defer f.Close()
-	for i := range lines {
-		_, err = f.WriteString(fmt.Sprintf("%s\n", lines[i]))
-		if err != nil {
-			color.PrintFatal(err)
-		}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
 	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fileName)
 }
 
 // changes directory fatally exists if an error is encountered
@@ -181,11 +250,17 @@ func Chdir(path string) {
 	}
 }
 
+// Returns a channel that receives SIGTERM and Ctrl+C SIGINT
+func NotifySIGTERM() <-chan os.Signal {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	return c
+}
+
 // Starts a channel listening for SIGTERM Ctrl+C and invokes the callback
 func HandleSIGTERM(cb func()) {
 	//cleanup func upon Ctrl+C SIGINT or SIGTERM
-	c := make(chan os.Signal)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	c := NotifySIGTERM()
 	go func() {
 		<-c
 		cb()
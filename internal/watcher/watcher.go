@@ -0,0 +1,184 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watches files for changes and reports them in batches once no change happened for the debounce duration,
+// editors often write a file several times on save which should only trigger a single reload
+type Watcher struct {
+	fs       *fsnotify.Watcher
+	debounce time.Duration
+
+	mu       sync.Mutex
+	patterns map[string][]string
+	trees    []string
+	ignore   []string
+
+	events chan []string
+	errors chan error
+}
+
+// Creates a watcher and starts listening for file system events
+func New(debounce time.Duration) (*Watcher, error) {
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{
+		fs:       fs,
+		debounce: debounce,
+		patterns: make(map[string][]string),
+		events:   make(chan []string),
+		errors:   make(chan error),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Watches a file or a glob pattern EG. /project/conf.d/*.conf. The parent directory is watched
+// instead of the file so files that editors replace on save are still picked up
+func (w *Watcher) Add(pattern string) error {
+	pattern, err := filepath.Abs(pattern)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(pattern)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.patterns[dir]; !ok {
+		if err := w.fs.Add(dir); err != nil {
+			return err
+		}
+	}
+	w.patterns[dir] = append(w.patterns[dir], pattern)
+	return nil
+}
+
+// Watches every file below dir, directories that are created later on are watched as well
+func (w *Watcher) AddTree(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.trees = append(w.trees, dir)
+	w.mu.Unlock()
+	return w.addDirs(dir)
+}
+
+// Ignores changes of files or directories whose name or path relative to a watched tree
+// matches one of the patterns EG. node_modules, *.swp or build/*
+func (w *Watcher) Ignore(patterns ...string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ignore = append(w.ignore, patterns...)
+}
+
+// Receives the changed paths of every batch
+func (w *Watcher) Events() <-chan []string { return w.events }
+
+// Receives errors of the underlying file system watcher
+func (w *Watcher) Errors() <-chan error { return w.errors }
+
+// Stops watching, the events channel is closed
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}
+
+func (w *Watcher) matches(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, pattern := range w.patterns[filepath.Dir(name)] {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	for _, tree := range w.trees {
+		if rel, err := filepath.Rel(tree, name); err == nil && !strings.HasPrefix(rel, "..") {
+			return !w.ignored(rel)
+		}
+	}
+	return false
+}
+
+// Checks every path segment so that files inside an ignored directory are ignored too
+func (w *Watcher) ignored(rel string) bool {
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for _, pattern := range w.ignore {
+		if ok, _ := filepath.Match(pattern, filepath.ToSlash(rel)); ok {
+			return true
+		}
+		for i := range segments {
+			if ok, _ := filepath.Match(pattern, segments[i]); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Watches dir and its sub directories that are not ignored
+func (w *Watcher) addDirs(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		if path != dir && !w.matches(path) {
+			return filepath.SkipDir
+		}
+		return w.fs.Add(path)
+	})
+}
+
+func (w *Watcher) run() {
+	defer close(w.events)
+	var (
+		changed = make(map[string]bool)
+		timer   = time.NewTimer(w.debounce)
+		pending <-chan time.Time
+	)
+	timer.Stop()
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			//chmod alone does not change the content
+			if event.Op == fsnotify.Chmod || !w.matches(event.Name) {
+				continue
+			}
+			//new directories of a tree are not watched by fsnotify until they are added
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addDirs(event.Name); err != nil {
+						w.errors <- err
+					}
+				}
+			}
+			changed[event.Name] = true
+			timer.Reset(w.debounce)
+			pending = timer.C
+		case <-pending:
+			pending = nil
+			batch := make([]string, 0, len(changed))
+			for name := range changed {
+				batch = append(batch, name)
+			}
+			changed = make(map[string]bool)
+			w.events <- batch
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			w.errors <- err
+		}
+	}
+}